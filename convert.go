@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"flo_energy_take_home/sink"
+	"flo_energy_take_home/sql"
+	"flo_energy_take_home/util"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runConvert parses a NEM12 file and writes its readings in the selected output
+// format. It is the command run when none is given.
+func runConvert(args []string) error {
+	start := time.Now()
+	fs := newFlagSet("convert", "Converts a NEM12 file to SQL statement files, or another output format.")
+	filename := fs.String("file", "", "CSV file to read")
+	batchSize := fs.Int("batch", 10000, "Maximum number of readings per sql file or Parquet row group")
+	maxFileSize := fs.String("max-file-size", "", "Maximum size of each sql file, e.g. 50MB (overrides the --batch default)")
+	tables := addTableFlags(fs)
+	deterministicIDs := fs.Bool("deterministic-ids", false, "Include a UUIDv5 ID derived from NMI, suffix and timestamp")
+	stagingMerge := fs.Bool("staging-merge", false, "Load every file into a staging table, then merge it into the target table in a final file")
+	replace := fs.Bool("replace", false, "Delete existing readings for each NMI, suffix and date range in the file before inserting")
+	dsn := fs.String("dsn", "", "Load straight into this Postgres database instead of writing SQL files")
+	ddl := fs.Bool("ddl", false, "Print the DDL for the target table and exit (see the ddl command)")
+	partitions := fs.Bool("partitions", false, "With --ddl, add monthly partitions covering the dates in --file")
+	outputFormat := fs.String("output-format", "sql", fmt.Sprintf("Output format, one of %s", strings.Join(sink.Formats(), ", ")))
+	parquetPartitions := fs.Bool("parquet-partitions", false, "With --output-format=parquet, split files into nmi=/date= directories")
+	splitByNMI := fs.Bool("split-by-nmi", false, "With --output-format=csv, write a file per NMI")
+	compression := fs.String("compress", "", "Compress SQL files with gzip or zstd")
+	compressionLevel := fs.Int("compress-level", 0, "Compression level for --compress, 1-9 for gzip or 1-22 for zstd (default the format's default)")
+	partitionBy := fs.String("partition-by", "", "Write SQL files into a directory per partition: nmi or month")
+	fileNameTemplate := fs.String("file-name-template", util.DefaultFileNameTemplate, "Name of each SQL file before its extension, from {seq} zero-padded to the width of the file count or {seq:N} to N digits, {run_id}, {source} and {partition}")
+	outPath := fs.String("out", "", "Write all SQL statements in order to this one file, or - for stdout, instead of a directory of files")
+	runID := fs.String("run-id", util.NewRunID(start), "Name of the directory under ./out this run writes into")
+	overwrite := fs.Bool("overwrite", false, "Replace the run's output directory, or the --out file, if it already exists")
+	//_ = fs.String("delimiter", ",", "CSV delimiter")
+
+	fs.Parse(args)
+
+	// With --out=-, stdout carries the SQL, so everything else goes to stderr
+	if *outPath == "-" {
+		diagnostics = os.Stderr
+	}
+
+	opts := tables.options()
+	if *deterministicIDs {
+		opts = append(opts, sql.WithDeterministicIDs())
+	}
+	if *stagingMerge {
+		if *dsn != "" {
+			return errors.New("error: --staging-merge is only supported when writing SQL files")
+		}
+		// The staged readings are merged once, after every partition's files
+		if *partitionBy != "" {
+			return errors.New("error: --staging-merge merges the whole run at once, so it can't be combined with --partition-by")
+		}
+		opts = append(opts, sql.WithStagingMerge())
+	}
+	if *replace {
+		if *dsn != "" {
+			return errors.New("error: --replace is only supported when writing SQL files")
+		}
+		opts = append(opts, sql.WithReplace())
+	}
+
+	// --dsn loads the database, so it selects the postgres sink unless told otherwise
+	format := *outputFormat
+	if *dsn != "" && !isFlagSet(fs, "output-format") {
+		format = "postgres"
+	}
+	if *dsn != "" && format != "postgres" {
+		return errors.New("error: --dsn is only supported with --output-format=postgres")
+	}
+
+	// The table options and load modes only shape generated SQL, so other
+	// formats would silently ignore them
+	if format != "sql" && format != "postgres" {
+		for _, option := range []struct {
+			flag string
+			set  bool
+		}{
+			{"--watt-hours", *tables.wattHours},
+			{"--schema", *tables.schema != ""},
+			{"--table-prefix", *tables.tablePrefix != ""},
+			{"--table-suffix", *tables.tableSuffix != ""},
+			{"--rollups", *tables.rollups},
+			{"--deterministic-ids", *deterministicIDs},
+			{"--staging-merge", *stagingMerge},
+			{"--replace", *replace},
+			{"--max-file-size", *maxFileSize != ""},
+		} {
+			if option.set {
+				return fmt.Errorf("error: %s is only supported when writing SQL", option.flag)
+			}
+		}
+		if *tables.layout == "days" && format != "jsonl" {
+			return fmt.Errorf("error: --layout=days is not supported with --output-format=%s", format)
+		}
+	}
+
+	if *compression == "" && isFlagSet(fs, "compress-level") {
+		return errors.New("error: --compress-level needs --compress")
+	}
+	if *compression != "" {
+		if format != "sql" {
+			return errors.New("error: --compress is only supported when writing SQL files")
+		}
+		if err := util.ValidateCompression(*compression, *compressionLevel); err != nil {
+			return err
+		}
+	}
+
+	if isFlagSet(fs, "file-name-template") {
+		if format != "sql" {
+			return errors.New("error: --file-name-template is only supported when writing SQL files")
+		}
+		if _, err := util.ParseFileNameTemplate(*fileNameTemplate); err != nil {
+			return err
+		}
+	}
+
+	if *partitionBy != "" {
+		if format != "sql" {
+			return errors.New("error: --partition-by is only supported when writing SQL files")
+		}
+		if *partitionBy != sql.PartitionByNMI && *partitionBy != sql.PartitionByMonth {
+			return errors.New("error: --partition-by must be nmi or month")
+		}
+		opts = append(opts, sql.WithPartitionBy(*partitionBy))
+	}
+
+	if *outPath != "" && (format != "sql" || *partitionBy != "" || *compression != "" || isFlagSet(fs, "file-name-template")) {
+		return errors.New("error: --out only supports plain SQL, without --partition-by, --compress or --file-name-template")
+	}
+	if *outPath != "" && isFlagSet(fs, "run-id") {
+		return errors.New("error: --run-id names an output directory, so it can't be combined with --out")
+	}
+
+	layout := *tables.layout
+	if layout != "rows" && layout != "days" {
+		return errors.New("error: --layout must be rows or days")
+	}
+	if layout == "days" && (*dsn != "" || *stagingMerge || *replace || *maxFileSize != "" || *deterministicIDs || *partitions) {
+		return errors.New("error: --layout=days only supports writing SQL files with the --batch, --watt-hours, --partition-by and table options")
+	}
+
+	if *maxFileSize != "" {
+		// Loaded statements aren't written to files, so there is no size to limit
+		if *dsn != "" {
+			return errors.New("error: --max-file-size is only supported when writing SQL files")
+		}
+		size, err := util.ParseByteSize(*maxFileSize)
+		if err != nil {
+			return err
+		}
+		if size <= int64(util.TransactionOverhead) {
+			return fmt.Errorf("error: --max-file-size must be more than %d bytes", util.TransactionOverhead)
+		}
+		opts = append(opts, sql.WithMaxStatementSize(size-int64(util.TransactionOverhead)))
+		// Without an explicit --batch, files are limited by size alone
+		if !isFlagSet(fs, "batch") {
+			*batchSize = 0
+		}
+	}
+
+	if *ddl {
+		return printDDL(filename, fs.Usage, layout, *partitions, *tables.rollups, opts)
+	}
+
+	readings, err := readFile(filename, fs.Usage)
+	if err != nil {
+		return err
+	}
+
+	// Every run writes into a directory of its own, so it never mixes with or
+	// clobbers the output of an earlier run, unless --out names a single stream
+	outputDir := ""
+	if format != "postgres" && *outPath == "" {
+		outputDir, err = util.PrepareRunDir("./out", *runID, *overwrite)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(diagnostics, "Writing output to %s\n", outputDir)
+	}
+
+	out, err := sink.New(format, sink.Config{
+		OutputDir:  outputDir,
+		Out:        *outPath,
+		Overwrite:  *overwrite,
+		DSN:        *dsn,
+		BatchSize:  *batchSize,
+		SQLOptions: opts,
+		Layout:     layout,
+		Rollups:    *tables.rollups,
+
+		RunID:             *runID,
+		Source:            util.SourceName(*filename),
+		FileNameTemplate:  *fileNameTemplate,
+		Compression:       *compression,
+		CompressionLevel:  *compressionLevel,
+		ParquetPartitions: *parquetPartitions,
+		SplitByNMI:        *splitByNMI,
+	})
+	if err != nil {
+		return err
+	}
+	if err := sink.WriteAll(out, readings); err != nil {
+		return err
+	}
+	fmt.Fprintf(diagnostics, "%.2fs elapsed\n", time.Since(start).Seconds())
+	return nil
+}
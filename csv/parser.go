@@ -12,9 +12,39 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
-func ParallelProcessNEM12File(file *os.File) ([]model.MeterReadings, error) {
+// NEMTime is the time zone of NEM12 interval dates. The market runs on Australian
+// Eastern Standard Time all year, without daylight saving.
+var NEMTime = time.FixedZone("AEST", 10*60*60)
+
+// InNEMTime returns the instant of a parsed timestamp. The parser keeps the
+// date and time written in the file, in UTC, so outputs that store instants
+// rather than wall clock times convert with this.
+func InNEMTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), NEMTime)
+}
+
+// ReadingDay returns the start of the day the interval ending at timestamp
+// belongs to. Timestamps mark the end of an interval, so one ending at midnight
+// belongs to the day before.
+func ReadingDay(timestamp time.Time) time.Time {
+	t := timestamp.Add(-time.Nanosecond)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Reading is an interval reading parsed from a NEM12 file: the meter_readings
+// row, with the details of its channel and interval that the table doesn't store.
+type Reading struct {
+	model.MeterReadings
+	IntervalLength int32
+	QualityMethod  string
+	Uom            string
+}
+
+func ParallelProcessNEM12File(file *os.File) ([]Reading, error) {
 	numWorkers := runtime.NumCPU()
 	chunks, err := splitFileIntoChunks(file, numWorkers)
 	if err != nil {
@@ -22,7 +52,7 @@ func ParallelProcessNEM12File(file *os.File) ([]model.MeterReadings, error) {
 	}
 
 	var wg sync.WaitGroup
-	readingsChan := make(chan []model.MeterReadings, numWorkers)
+	readingsChan := make(chan []Reading, numWorkers)
 	errorsChan := make(chan error, numWorkers)
 
 	for _, chunk := range chunks {
@@ -44,7 +74,7 @@ func ParallelProcessNEM12File(file *os.File) ([]model.MeterReadings, error) {
 		close(errorsChan)
 	}()
 
-	var allReadings []model.MeterReadings
+	var allReadings []Reading
 	for readings := range readingsChan {
 		allReadings = append(allReadings, readings...)
 	}
@@ -101,13 +131,18 @@ func splitFileIntoChunks(file *os.File, numChunks int) ([][]string, error) {
 	return chunks, nil
 }
 
-func processChunk(chunk []string) ([]model.MeterReadings, error) {
+func processChunk(chunk []string) ([]Reading, error) {
 	reader := csv.NewReader(strings.NewReader(strings.Join(chunk, "\n")))
 	reader.FieldsPerRecord = -1 // Allow variable number of fields
 
-	var readings []model.MeterReadings
+	var readings []Reading
 	var currentNMI string
+	var currentSuffix string
+	var currentUOM string
 	var currentIntervalLength int
+	// Index into readings of each interval of the last 300 record, or -1 where
+	// the interval was blank, so 400 records can set per-interval quality
+	var dayReadings []int
 
 	for {
 		record, err := reader.Read()
@@ -128,6 +163,8 @@ func processChunk(chunk []string) ([]model.MeterReadings, error) {
 				return nil, fmt.Errorf("invalid 200 record: not enough fields. record: %v", record)
 			}
 			currentNMI = record[1]
+			currentSuffix = record[4]
+			currentUOM = record[7]
 			intervalLength, err := strconv.Atoi(record[8])
 			if err != nil {
 				return nil, fmt.Errorf("invalid interval length: %v. record: %v", err, record)
@@ -136,6 +173,7 @@ func processChunk(chunk []string) ([]model.MeterReadings, error) {
 				return nil, fmt.Errorf("invalid interval length, must be one of 5, 15 or 30. record: %v", record)
 			}
 			currentIntervalLength = intervalLength
+			dayReadings = nil
 
 		case "300":
 			if len(record) < 3 {
@@ -155,24 +193,64 @@ func processChunk(chunk []string) ([]model.MeterReadings, error) {
 				return nil, fmt.Errorf("invalid number of intervals: %d. record: %v", numberOfIntervals, record)
 			}
 
+			qualityMethod := record[2+numberOfIntervals]
+			dayReadings = make([]int, numberOfIntervals)
+
 			for i, v := range record[2 : 2+numberOfIntervals] {
+				dayReadings[i] = -1
 				if v == "" {
 					continue
 				}
-				value, err := strconv.ParseFloat(v, 64)
+				// Keep the value exactly as the meter reported it; a float would round it
+				value, err := decimal.NewFromString(v)
 				if err != nil {
 					return nil, fmt.Errorf("invalid consumption value: %v. record: %v", err, record)
 				}
 				timestamp := date.Add(time.Duration(i*currentIntervalLength) * time.Minute)
-				reading := model.MeterReadings{
-					Nmi:         currentNMI,
-					Timestamp:   timestamp,
-					Consumption: value,
+				reading := Reading{
+					MeterReadings: model.MeterReadings{
+						Nmi:         currentNMI,
+						NmiSuffix:   currentSuffix,
+						Timestamp:   timestamp,
+						Consumption: value,
+					},
+					Uom:            currentUOM,
+					IntervalLength: int32(currentIntervalLength),
+					QualityMethod:  qualityMethod,
 				}
+				dayReadings[i] = len(readings)
 				readings = append(readings, reading)
 			}
+
+		case "400":
+			if err := applyIntervalEvent(record, dayReadings, readings); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return readings, nil
 }
+
+// applyIntervalEvent sets the quality method of a 400 record on the readings of
+// the intervals it covers. dayReadings indexes into readings for each interval
+// of the preceding 300 record, with -1 where the interval was blank.
+func applyIntervalEvent(record []string, dayReadings []int, readings []Reading) error {
+	if len(record) < 4 {
+		return fmt.Errorf("invalid 400 record: not enough fields. record: %v", record)
+	}
+	if dayReadings == nil {
+		return fmt.Errorf("invalid 400 record: no preceding 300 record. record: %v", record)
+	}
+	start, startErr := strconv.Atoi(record[1])
+	end, endErr := strconv.Atoi(record[2])
+	if startErr != nil || endErr != nil || start < 1 || start > end || end > len(dayReadings) {
+		return fmt.Errorf("invalid 400 record: interval range must be within 1 to %d. record: %v", len(dayReadings), record)
+	}
+	for _, index := range dayReadings[start-1 : end] {
+		if index >= 0 {
+			readings[index].QualityMethod = record[3]
+		}
+	}
+	return nil
+}
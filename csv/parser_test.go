@@ -1,16 +1,17 @@
 package csv
 
 import (
-	"flo_energy_take_home/db/test_flo/public/model"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestParallelProcessNEM12File(t *testing.T) {
-	runTestCases(t, func(content string) ([]model.MeterReadings, error) {
+	runTestCases(t, func(content string) ([]Reading, error) {
 		// Create a temporary file
 		tmpfile, err := os.CreateTemp("", "test*.csv")
 		if err != nil {
@@ -34,14 +35,14 @@ func TestParallelProcessNEM12File(t *testing.T) {
 	})
 }
 
-func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, error)) {
+func runTestCases(t *testing.T, processFn func(string) ([]Reading, error)) {
 	tests := []struct {
 		name          string
 		input         string
 		expectedLen   int
 		expectedNMI   string
 		expectedTime  string
-		expectedValue float64
+		expectedValue decimal.Decimal
 		expectError   bool
 		errorMessage  string
 	}{
@@ -54,7 +55,7 @@ func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, e
 			expectedLen:   48,
 			expectedNMI:   "NEM1201009",
 			expectedTime:  "2005-03-01 00:30:00",
-			expectedValue: 0,
+			expectedValue: decimal.Zero,
 			expectError:   false,
 		},
 		{
@@ -66,7 +67,7 @@ func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, e
 			expectedLen:   96,
 			expectedNMI:   "NEM1201010",
 			expectedTime:  "2005-03-01 00:15:00",
-			expectedValue: 0,
+			expectedValue: decimal.Zero,
 			expectError:   false,
 		},
 		{
@@ -78,7 +79,7 @@ func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, e
 			expectedLen:   288,
 			expectedNMI:   "NEM1201011",
 			expectedTime:  "2005-03-01 00:05:00",
-			expectedValue: 0,
+			expectedValue: decimal.Zero,
 			expectError:   false,
 		},
 		{
@@ -147,7 +148,7 @@ func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, e
 			expectedLen:   96,
 			expectedNMI:   "",
 			expectedTime:  "2005-03-01 00:30:00",
-			expectedValue: 0,
+			expectedValue: decimal.Zero,
 			expectError:   false,
 		},
 	}
@@ -181,8 +182,8 @@ func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, e
 					if !firstReading.Timestamp.Equal(expectedTime) {
 						t.Errorf("Expected timestamp %v, but got %v", expectedTime, firstReading.Timestamp)
 					}
-					if firstReading.Consumption != tt.expectedValue {
-						t.Errorf("Expected consumption %f, but got %f", tt.expectedValue, firstReading.Consumption)
+					if !firstReading.Consumption.Equal(tt.expectedValue) {
+						t.Errorf("Expected consumption %s, but got %s", tt.expectedValue, firstReading.Consumption)
 					}
 				}
 			}
@@ -190,6 +191,127 @@ func runTestCases(t *testing.T, processFn func(string) ([]model.MeterReadings, e
 	}
 }
 
+func TestProcessChunkKeepsExactConsumption(t *testing.T) {
+	chunk := []string{
+		"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610",
+		"300,20050301,0.1,0.2,0.3,0.4,0.5,0.6,0.7,0.8,0.9,1.0,1.1,1.2,1.3,1.4,1.5,1.6,1.7,1.8,1.9,2.0,2.1,2.2,2.3,2.4,2.5,2.6,2.7,2.8,2.9,3.0,3.1,3.2,3.3,3.4,3.5,3.6,3.7,3.8,3.9,4.0,4.1,4.2,4.3,4.4,4.5,4.6,4.7,123456789.0123456789,A,,,20050310121004,20050310182204",
+	}
+
+	readings, err := processChunk(chunk)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 0.1 + ... + 4.7 is 112.8 exactly, which float64 addition does not reproduce
+	total := decimal.Zero
+	for _, reading := range readings[:47] {
+		total = total.Add(reading.Consumption)
+	}
+	if total.String() != "112.8" {
+		t.Errorf("Expected total 112.8, but got %s", total)
+	}
+
+	if last := readings[47].Consumption.String(); last != "123456789.0123456789" {
+		t.Errorf("Expected consumption 123456789.0123456789, but got %s", last)
+	}
+}
+
+func TestProcessChunkChannelDetails(t *testing.T) {
+	day := "0,0,0,0,0,0,0,0,0,0,0,0,0.461,0.810,0.568,1.234,1.353,1.507,1.344,1.773,0.848,1.271,0.895,1.327,1.013,1.793,0.988,0.985,0.876,0.555,0.760,0.938,0.566,0.512,0.970,0.760,0.731,0.615,0.886,0.531,0.774,0.712,0.598,0.670,0.587,0.657,0.345,0.231"
+	chunk := []string{
+		"200,NEM1201009,E1Q1,1,E1,N1,01009,kWh,30,20050610",
+		"300,20050301," + day + ",A,,,20050310121004,20050310182204",
+		"200,NEM1201009,E1Q1,2,Q1,,01009,kVArh,30,20050610",
+		"300,20050301," + day + ",A,,,20050310121004,20050310182204",
+	}
+
+	readings, err := processChunk(chunk)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(readings) != 96 {
+		t.Fatalf("Expected 96 readings, but got %d", len(readings))
+	}
+	if readings[0].NmiSuffix != "E1" || readings[0].Uom != "kWh" {
+		t.Errorf("Expected suffix E1 in kWh, but got %s in %s", readings[0].NmiSuffix, readings[0].Uom)
+	}
+	if readings[48].NmiSuffix != "Q1" || readings[48].Uom != "kVArh" {
+		t.Errorf("Expected suffix Q1 in kVArh, but got %s in %s", readings[48].NmiSuffix, readings[48].Uom)
+	}
+}
+
+func TestProcessChunkQualityMethods(t *testing.T) {
+	day := "0,0,0,0,0,0,0,0,0,0,0,0,0.461,0.810,0.568,1.234,1.353,1.507,1.344,1.773,0.848,1.271,0.895,1.327,1.013,1.793,0.988,0.985,0.876,0.555,0.760,0.938,0.566,0.512,0.970,0.760,0.731,0.615,0.886,0.531,0.774,0.712,0.598,0.670,0.587,0.657,0.345,0.231"
+
+	tests := []struct {
+		name            string
+		chunk           []string
+		expectedQuality map[int]string
+		errorMessage    string
+	}{
+		{
+			name: "Quality from the 300 record",
+			chunk: []string{
+				"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610",
+				"300,20050301," + day + ",A,,,20050310121004,20050310182204",
+			},
+			expectedQuality: map[int]string{0: "A", 47: "A"},
+		},
+		{
+			name: "Variable quality from 400 records",
+			chunk: []string{
+				"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610",
+				"300,20050301," + day + ",V,,,20050310121004,20050310182204",
+				"400,1,20,A,,",
+				"400,21,48,S53,32,Estimated",
+			},
+			expectedQuality: map[int]string{0: "A", 19: "A", 20: "S53", 47: "S53"},
+		},
+		{
+			name: "400 record out of range",
+			chunk: []string{
+				"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610",
+				"300,20050301," + day + ",V,,,20050310121004,20050310182204",
+				"400,21,49,S53,32,Estimated",
+			},
+			errorMessage: "interval range must be within 1 to 48",
+		},
+		{
+			name: "400 record without a 300 record",
+			chunk: []string{
+				"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610",
+				"400,1,48,A,,",
+			},
+			errorMessage: "no preceding 300 record",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readings, err := processChunk(tt.chunk)
+
+			if tt.errorMessage != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMessage) {
+					t.Errorf("Expected error message to contain '%s', but got: %v", tt.errorMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for index, quality := range tt.expectedQuality {
+				if readings[index].QualityMethod != quality {
+					t.Errorf("Expected quality %s for interval %d, but got %s", quality, index+1, readings[index].QualityMethod)
+				}
+				if readings[index].IntervalLength != 30 {
+					t.Errorf("Expected interval length 30, but got %d", readings[index].IntervalLength)
+				}
+			}
+		})
+	}
+}
+
 func TestSplitFileIntoChunks(t *testing.T) {
 	content := `100,NEM12,200506081149,UNITEDDP,NEMMCO
 200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610
@@ -236,3 +358,23 @@ func TestSplitFileIntoChunks(t *testing.T) {
 		t.Errorf("Second chunk should start with 200 record for NEM1201010, but got: %s", chunks[1][0])
 	}
 }
+
+func TestReadingDay(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		expected  time.Time
+	}{
+		{name: "Interval during the day", timestamp: time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC), expected: time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Interval ending at midnight", timestamp: time.Date(2005, 3, 2, 0, 0, 0, 0, time.UTC), expected: time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Interval ending at the end of a month", timestamp: time.Date(2005, 4, 1, 0, 0, 0, 0, time.UTC), expected: time.Date(2005, 3, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if day := ReadingDay(tt.timestamp); !day.Equal(tt.expected) {
+				t.Errorf("Expected %v, but got %v", tt.expected, day)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// qualityMethod matches a NEM12 quality flag, optionally followed by a two digit
+// method, such as A, E52 or S14.
+var qualityMethod = regexp.MustCompile(`^[AEFNSV]([0-9]{2})?$`)
+
+// Problem is something wrong with a NEM12 file, at a line counted from 1. Line
+// is 0 for problems with the file as a whole.
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Report is the outcome of validating a NEM12 file.
+type Report struct {
+	// Records counts the records of each type, such as "200" or "300"
+	Records map[string]int
+	// NMIs are the NMIs of the 200 records, sorted
+	NMIs []string
+	// Channels is the number of distinct NMI and suffix pairs
+	Channels int
+	// Readings is the number of non-blank interval values
+	Readings int
+	// From and To are the first and last interval dates of the 300 records
+	From, To time.Time
+	Problems []Problem
+}
+
+// Valid reports whether no problems were found.
+func (r Report) Valid() bool {
+	return len(r.Problems) == 0
+}
+
+// validator holds the state of a file as it is read record by record.
+type validator struct {
+	report Report
+	nmis   map[string]bool
+	// days holds the dates already seen for each NMI and suffix
+	days map[string]map[string]bool
+
+	seenHeader, seenEnd bool
+	// channel is the NMI and suffix of the current 200 record, or "" before the
+	// first and after an invalid one
+	channel        string
+	intervalLength int
+	// intervals is the number of intervals of the last 300 record, or 0 when
+	// there's no 300 record for a 400 record to follow
+	intervals int
+	// variableLine is the line of the last 300 record with a V quality flag,
+	// while it still needs a 400 record
+	variableLine int
+	lines        []string
+}
+
+// Validate reads a whole NEM12 file and checks its structure and values,
+// collecting every problem rather than stopping at the first. A file without
+// problems is also run through the parser, so it is known to convert.
+func Validate(r io.Reader) (Report, error) {
+	v := &validator{
+		report: Report{Records: make(map[string]int)},
+		nmis:   make(map[string]bool),
+		days:   make(map[string]map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		v.lines = append(v.lines, text)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		record, err := csv.NewReader(strings.NewReader(text)).Read()
+		if err != nil {
+			v.problem(line, "invalid CSV: %v", err)
+			continue
+		}
+		v.record(line, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return Report{}, fmt.Errorf("error reading file: %v", err)
+	}
+	v.finish()
+
+	if v.report.Valid() {
+		if _, err := processChunk(v.lines); err != nil {
+			v.problem(0, "the parser rejected the file: %v", err)
+		}
+	}
+	return v.report, nil
+}
+
+func (v *validator) problem(line int, format string, args ...interface{}) {
+	v.report.Problems = append(v.report.Problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) record(line int, record []string) {
+	recordType := record[0]
+	v.report.Records[recordType]++
+
+	if v.seenEnd {
+		v.problem(line, "%s record after the 900 end record", recordType)
+	}
+	if !v.seenHeader && recordType != "100" {
+		v.problem(line, "%s record before the 100 header record", recordType)
+		// Only report the missing header once
+		v.seenHeader = true
+	}
+	if recordType != "400" {
+		v.endVariableDay()
+	}
+
+	switch recordType {
+	case "100":
+		v.header(line, record)
+	case "200":
+		v.nmiDataDetails(line, record)
+	case "300":
+		v.intervalData(line, record)
+	case "400":
+		v.intervalEvent(line, record)
+	case "500", "900":
+		v.intervals = 0
+		if recordType == "900" {
+			v.seenEnd = true
+		}
+	default:
+		v.problem(line, "unknown record type %q", recordType)
+	}
+}
+
+func (v *validator) header(line int, record []string) {
+	if v.report.Records["100"] > 1 {
+		v.problem(line, "more than one 100 header record")
+	}
+	v.seenHeader = true
+	if len(record) < 2 || record[1] != "NEM12" {
+		v.problem(line, "100 record is not for a NEM12 file")
+	}
+}
+
+func (v *validator) nmiDataDetails(line int, record []string) {
+	v.channel = ""
+	v.intervals = 0
+	if len(record) < 9 {
+		v.problem(line, "200 record has %d fields, expected at least 9", len(record))
+		return
+	}
+
+	nmi, suffix, uom := record[1], record[4], record[7]
+	valid := true
+	if len(nmi) != 10 {
+		v.problem(line, "NMI %q is not 10 characters", nmi)
+		valid = false
+	}
+	if suffix == "" {
+		v.problem(line, "200 record has no NMI suffix")
+		valid = false
+	}
+	if uom == "" {
+		v.problem(line, "200 record has no unit of measure")
+	}
+	intervalLength, err := strconv.Atoi(record[8])
+	if err != nil || !(intervalLength == 5 || intervalLength == 15 || intervalLength == 30) {
+		v.problem(line, "interval length %q must be one of 5, 15 or 30", record[8])
+		valid = false
+	}
+	if !valid {
+		return
+	}
+
+	if !v.nmis[nmi] {
+		v.nmis[nmi] = true
+		v.report.NMIs = append(v.report.NMIs, nmi)
+		sort.Strings(v.report.NMIs)
+	}
+	v.channel = nmi + "|" + suffix
+	if v.days[v.channel] == nil {
+		v.days[v.channel] = make(map[string]bool)
+		v.report.Channels++
+	}
+	v.intervalLength = intervalLength
+}
+
+func (v *validator) intervalData(line int, record []string) {
+	v.intervals = 0
+	if v.channel == "" {
+		v.problem(line, "300 record without a valid 200 record before it")
+		return
+	}
+	numberOfIntervals := 1440 / v.intervalLength
+	if len(record) < numberOfIntervals+3 || len(record) > numberOfIntervals+7 {
+		v.problem(line, "300 record has %d fields, expected %d intervals and a quality method", len(record), numberOfIntervals)
+		return
+	}
+	v.intervals = numberOfIntervals
+
+	date, err := time.Parse("20060102", record[1])
+	if err != nil {
+		v.problem(line, "invalid interval date %q", record[1])
+	} else {
+		if v.days[v.channel][record[1]] {
+			v.problem(line, "interval date %s repeated for %s", record[1], strings.Replace(v.channel, "|", " ", 1))
+		}
+		v.days[v.channel][record[1]] = true
+		if v.report.From.IsZero() || date.Before(v.report.From) {
+			v.report.From = date
+		}
+		if date.After(v.report.To) {
+			v.report.To = date
+		}
+	}
+
+	for i, value := range record[2 : 2+numberOfIntervals] {
+		if value == "" {
+			continue
+		}
+		consumption, err := decimal.NewFromString(value)
+		if err != nil {
+			v.problem(line, "interval %d value %q is not a number", i+1, value)
+			continue
+		}
+		if consumption.IsNegative() {
+			v.problem(line, "interval %d value %s is negative", i+1, value)
+		}
+		v.report.Readings++
+	}
+
+	quality := record[2+numberOfIntervals]
+	if !qualityMethod.MatchString(quality) {
+		v.problem(line, "invalid quality method %q", quality)
+	} else if quality[0] == 'V' {
+		v.variableLine = line
+	}
+}
+
+func (v *validator) intervalEvent(line int, record []string) {
+	if v.intervals == 0 {
+		v.problem(line, "400 record without a valid 300 record before it")
+		return
+	}
+	if len(record) < 4 {
+		v.problem(line, "400 record has %d fields, expected at least 4", len(record))
+		return
+	}
+	start, startErr := strconv.Atoi(record[1])
+	end, endErr := strconv.Atoi(record[2])
+	if startErr != nil || endErr != nil || start < 1 || start > end || end > v.intervals {
+		v.problem(line, "400 record interval range %s to %s is not within 1 to %d", record[1], record[2], v.intervals)
+	}
+	if !qualityMethod.MatchString(record[3]) || record[3][0] == 'V' {
+		v.problem(line, "invalid 400 record quality method %q", record[3])
+	}
+	v.variableLine = 0
+}
+
+// endVariableDay reports a 300 record flagged V that no 400 record followed.
+func (v *validator) endVariableDay() {
+	if v.variableLine != 0 {
+		v.problem(v.variableLine, "300 record has quality method V but no 400 records")
+		v.variableLine = 0
+	}
+}
+
+func (v *validator) finish() {
+	v.endVariableDay()
+	if len(v.report.Records) == 0 {
+		v.problem(0, "the file has no records")
+		return
+	}
+	if !v.seenEnd {
+		v.problem(0, "the file has no 900 end record")
+	}
+	if v.report.Records["900"] > 1 {
+		v.problem(0, "the file has more than one 900 end record")
+	}
+	if v.report.Records["300"] == 0 {
+		v.problem(0, "the file has no 300 interval data records")
+	}
+}
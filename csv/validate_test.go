@@ -0,0 +1,157 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// day300 returns a 30 minute 300 record for date with every interval set to value.
+func day300(date, value, quality string) string {
+	return "300," + date + strings.Repeat(","+value, 48) + "," + quality + ",,,20050310121004,20050310182204"
+}
+
+func TestValidate(t *testing.T) {
+	header := "100,NEM12,200506081149,UNITEDDP,NEMMCO"
+	nmi1 := "200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610"
+	nmi2 := "200,NEM1201010,E1E2,2,E2,N2,01010,kWh,30,20050610"
+	end := "900"
+
+	tests := []struct {
+		name             string
+		lines            []string
+		expectedProblems []string
+	}{
+		{
+			name: "Valid file",
+			lines: []string{
+				header, nmi1, day300("20050301", "0.5", "A"), day300("20050302", "0.5", "V"),
+				"400,1,20,F14,76,,", "400,21,48,A,,,",
+				nmi2, day300("20050301", "1", "E52"),
+				"500,O,S01009,20050310121004,", end,
+			},
+		},
+		{
+			name:             "Missing header and end records",
+			lines:            []string{nmi1, day300("20050301", "0.5", "A")},
+			expectedProblems: []string{"line 1: 200 record before the 100 header record", "the file has no 900 end record"},
+		},
+		{
+			name: "Invalid 200 records",
+			lines: []string{
+				header,
+				"200,NEM12,E1E2,1,E1,N1,01009,kWh,30,20050610",
+				day300("20050301", "0.5", "A"),
+				"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,10,20050610",
+				end,
+			},
+			expectedProblems: []string{
+				`line 2: NMI "NEM12" is not 10 characters`,
+				"line 3: 300 record without a valid 200 record before it",
+				`line 4: interval length "10" must be one of 5, 15 or 30`,
+			},
+		},
+		{
+			name: "Invalid 300 values",
+			lines: []string{
+				header, nmi1,
+				strings.Replace(day300("20050301", "0.5", "A"), ",0.5,", ",abc,", 1),
+				strings.Replace(day300("20050302", "0.5", "A"), ",0.5,", ",-1,", 1),
+				day300("20050332", "0.5", "X"),
+				"300,20050303,1,2,3,A",
+				end,
+			},
+			expectedProblems: []string{
+				`line 3: interval 1 value "abc" is not a number`,
+				"line 4: interval 1 value -1 is negative",
+				`line 5: invalid interval date "20050332"`,
+				`line 5: invalid quality method "X"`,
+				"line 6: 300 record has 6 fields, expected 48 intervals and a quality method",
+			},
+		},
+		{
+			name: "Repeated day",
+			lines: []string{
+				header, nmi1, day300("20050301", "0.5", "A"), day300("20050301", "0.5", "A"), end,
+			},
+			expectedProblems: []string{"line 4: interval date 20050301 repeated for NEM1201009 E1"},
+		},
+		{
+			name: "Invalid 400 records",
+			lines: []string{
+				header, nmi1, "400,1,48,A,,,",
+				day300("20050301", "0.5", "V"),
+				day300("20050302", "0.5", "V"), "400,1,49,A,,,", "400,1,48,V,,,",
+				end,
+			},
+			expectedProblems: []string{
+				"line 3: 400 record without a valid 300 record before it",
+				"line 4: 300 record has quality method V but no 400 records",
+				"line 6: 400 record interval range 1 to 49 is not within 1 to 48",
+				`line 7: invalid 400 record quality method "V"`,
+			},
+		},
+		{
+			name:             "Unknown record and record after the end",
+			lines:            []string{header, nmi1, day300("20050301", "0.5", "A"), "250,x", end, day300("20050302", "0.5", "A")},
+			expectedProblems: []string{`line 4: unknown record type "250"`, "line 6: 300 record after the 900 end record"},
+		},
+		{
+			name:             "Empty file",
+			lines:            []string{""},
+			expectedProblems: []string{"the file has no records"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Validate(strings.NewReader(strings.Join(tt.lines, "\n")))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var problems []string
+			for _, problem := range report.Problems {
+				problems = append(problems, problem.String())
+			}
+			if strings.Join(problems, "\n") != strings.Join(tt.expectedProblems, "\n") {
+				t.Errorf("Expected problems:\n%s\nbut got:\n%s", strings.Join(tt.expectedProblems, "\n"), strings.Join(problems, "\n"))
+			}
+			if report.Valid() != (len(tt.expectedProblems) == 0) {
+				t.Errorf("Expected Valid() to be %v", len(tt.expectedProblems) == 0)
+			}
+		})
+	}
+}
+
+func TestValidateReport(t *testing.T) {
+	file := strings.Join([]string{
+		"100,NEM12,200506081149,UNITEDDP,NEMMCO",
+		"200,NEM1201010,E1E2,2,E2,N2,01010,kWh,30,20050610",
+		day300("20050302", "1", "A"),
+		"200,NEM1201009,E1E2,1,E1,N1,01009,kWh,30,20050610",
+		strings.Replace(day300("20050301", "0.5", "A"), ",0.5,", ",,", 1),
+		"900",
+	}, "\n")
+
+	report, err := Validate(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.Valid() {
+		t.Fatalf("Unexpected problems: %v", report.Problems)
+	}
+	if report.Records["100"] != 1 || report.Records["200"] != 2 || report.Records["300"] != 2 || report.Records["900"] != 1 {
+		t.Errorf("Unexpected record counts: %v", report.Records)
+	}
+	if strings.Join(report.NMIs, ",") != "NEM1201009,NEM1201010" || report.Channels != 2 {
+		t.Errorf("Unexpected NMIs %v and %d channels", report.NMIs, report.Channels)
+	}
+	// The blank interval is not a reading
+	if report.Readings != 95 {
+		t.Errorf("Expected 95 readings, but got %d", report.Readings)
+	}
+	if !report.From.Equal(time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)) || !report.To.Equal(time.Date(2005, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date range %v to %v", report.From, report.To)
+	}
+}
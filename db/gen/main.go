@@ -0,0 +1,67 @@
+// Command gen regenerates the jet models and tables under db/test_flo.
+//
+// It wraps the jet generator so that columns that must stay exact, such as
+// consumption, are generated as decimal.Decimal instead of float64.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-jet/jet/v2/generator/metadata"
+	"github.com/go-jet/jet/v2/generator/postgres"
+	"github.com/go-jet/jet/v2/generator/template"
+	postgres2 "github.com/go-jet/jet/v2/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// decimalColumns lists the columns generated as decimal.Decimal, by table.
+var decimalColumns = map[string][]string{
+	"meter_readings":         {"consumption"},
+	"meter_readings_hourly":  {"consumption"},
+	"meter_readings_daily":   {"consumption"},
+	"meter_readings_monthly": {"consumption"},
+}
+
+func main() {
+	dsn := flag.String("dsn", "", "Postgres connection string")
+	schema := flag.String("schema", "public", "Schema to generate")
+	path := flag.String("path", "./db/test_flo", "Output directory")
+	flag.Parse()
+
+	if *dsn == "" {
+		flag.Usage()
+		fmt.Println("error: dsn is required")
+		os.Exit(1)
+	}
+
+	err := postgres.GenerateDSN(*dsn, *schema, *path, template.Default(postgres2.Dialect).
+		UseSchema(func(schema metadata.Schema) template.Schema {
+			return template.DefaultSchema(schema).
+				UseModel(template.DefaultModel().
+					UseTable(func(table metadata.Table) template.TableModel {
+						return template.DefaultTableModel(table).
+							UseField(func(column metadata.Column) template.TableModelField {
+								field := template.DefaultTableModelField(column)
+								if isDecimalColumn(table.Name, column.Name) {
+									field.Type = template.NewType(decimal.Decimal{})
+								}
+								return field
+							})
+					}))
+		}))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func isDecimalColumn(table, column string) bool {
+	for _, name := range decimalColumns[table] {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
@@ -9,12 +9,14 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
 type MeterReadings struct {
 	ID          uuid.UUID `sql:"primary_key"`
 	Nmi         string
+	NmiSuffix   string
 	Timestamp   time.Time
-	Consumption float64
+	Consumption decimal.Decimal
 }
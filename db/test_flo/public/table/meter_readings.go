@@ -19,6 +19,7 @@ type meterReadingsTable struct {
 	// Columns
 	ID          postgres.ColumnString
 	Nmi         postgres.ColumnString
+	NmiSuffix   postgres.ColumnString
 	Timestamp   postgres.ColumnTimestamp
 	Consumption postgres.ColumnFloat
 
@@ -63,10 +64,11 @@ func newMeterReadingsTableImpl(schemaName, tableName, alias string) meterReading
 	var (
 		IDColumn          = postgres.StringColumn("id")
 		NmiColumn         = postgres.StringColumn("nmi")
+		NmiSuffixColumn   = postgres.StringColumn("nmi_suffix")
 		TimestampColumn   = postgres.TimestampColumn("timestamp")
 		ConsumptionColumn = postgres.FloatColumn("consumption")
-		allColumns        = postgres.ColumnList{IDColumn, NmiColumn, TimestampColumn, ConsumptionColumn}
-		mutableColumns    = postgres.ColumnList{NmiColumn, TimestampColumn, ConsumptionColumn}
+		allColumns        = postgres.ColumnList{IDColumn, NmiColumn, NmiSuffixColumn, TimestampColumn, ConsumptionColumn}
+		mutableColumns    = postgres.ColumnList{NmiColumn, NmiSuffixColumn, TimestampColumn, ConsumptionColumn}
 	)
 
 	return meterReadingsTable{
@@ -75,6 +77,7 @@ func newMeterReadingsTableImpl(schemaName, tableName, alias string) meterReading
 		//Columns
 		ID:          IDColumn,
 		Nmi:         NmiColumn,
+		NmiSuffix:   NmiSuffixColumn,
 		Timestamp:   TimestampColumn,
 		Consumption: ConsumptionColumn,
 
@@ -10,5 +10,9 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	MeterReadingDays = MeterReadingDays.FromSchema(schema)
 	MeterReadings = MeterReadings.FromSchema(schema)
+	MeterReadingsDaily = MeterReadingsDaily.FromSchema(schema)
+	MeterReadingsHourly = MeterReadingsHourly.FromSchema(schema)
+	MeterReadingsMonthly = MeterReadingsMonthly.FromSchema(schema)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/sql"
+	"flo_energy_take_home/util"
+	"fmt"
+	"os"
+)
+
+// runDDL prints the DDL for the tables the generated statements write into.
+func runDDL(args []string) error {
+	fs := newFlagSet("ddl", "Prints the DDL for the tables the generated statements write into.")
+	filename := fs.String("file", "", "CSV file to read the dates of monthly partitions from")
+	tables := addTableFlags(fs)
+	partitions := fs.Bool("partitions", false, "Add monthly partitions covering the dates in --file")
+	migrateSuffix := fs.Bool("migrate-suffix", false, "Print the statements that add nmi_suffix to a meter_readings table keyed on (nmi, timestamp) instead")
+	fs.Parse(args)
+
+	layout := *tables.layout
+	if layout != "rows" && layout != "days" {
+		return errors.New("error: --layout must be rows or days")
+	}
+	if layout == "days" && *partitions {
+		return errors.New("error: --layout=days does not support --partitions")
+	}
+	if *migrateSuffix {
+		if layout != "rows" || *partitions || *tables.rollups {
+			return errors.New("error: --migrate-suffix only applies to the rows layout, without --partitions or --rollups")
+		}
+		fmt.Print(sql.GenerateSuffixMigration(tables.options()...))
+		return nil
+	}
+	return printDDL(filename, fs.Usage, layout, *partitions, *tables.rollups, tables.options())
+}
+
+// printDDL writes the DDL for the target table, and the rollup tables if rollups
+// is set, to stdout. Partitions are derived from the readings in filename, so it
+// is only read when partitioned is set, and usage is printed if it's missing.
+func printDDL(filename *string, usage func(), layout string, partitioned, rollups bool, opts []sql.Option) error {
+	if layout == "days" {
+		fmt.Print(sql.GenerateDayArrayDDL(opts...))
+	} else if err := printReadingsDDL(filename, usage, partitioned, opts); err != nil {
+		return err
+	}
+	if rollups {
+		fmt.Print("\n" + sql.GenerateRollupDDL(opts...))
+	}
+	return nil
+}
+
+// printReadingsDDL writes the DDL for the meter_readings table to stdout.
+func printReadingsDDL(filename *string, usage func(), partitioned bool, opts []sql.Option) error {
+	if !partitioned {
+		fmt.Print(sql.GenerateDDL(opts...))
+		return nil
+	}
+
+	if err := util.ValidateFile(filename, usage); err != nil {
+		return err
+	}
+	file, err := os.Open(*filename)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	readings, err := csv.ParallelProcessNEM12File(file)
+	if err != nil {
+		return err
+	}
+
+	ddl, err := sql.GeneratePartitionedDDL(readings, opts...)
+	if err != nil {
+		return err
+	}
+	fmt.Print(ddl)
+	return nil
+}
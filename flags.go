@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"flo_energy_take_home/sql"
+	"fmt"
+)
+
+// tableFlags are the flags that select and shape the target tables, shared by
+// the commands that generate SQL.
+type tableFlags struct {
+	wattHours   *bool
+	schema      *string
+	tablePrefix *string
+	tableSuffix *string
+	layout      *string
+	rollups     *bool
+}
+
+func addTableFlags(fs *flag.FlagSet) tableFlags {
+	return tableFlags{
+		wattHours:   fs.Bool("watt-hours", false, "Store consumption as integer Wh instead of decimal kWh"),
+		schema:      fs.String("schema", "", "Target schema (default public)"),
+		tablePrefix: fs.String("table-prefix", "", "Prefix for the meter_readings table name"),
+		tableSuffix: fs.String("table-suffix", "", "Suffix for the meter_readings table name"),
+		layout:      fs.String("layout", "rows", "Table layout: rows (one row per interval) or days (one row per NMI and day, with interval arrays)"),
+		rollups:     fs.Bool("rollups", false, "Also write hourly, daily and monthly totals per NMI and suffix to their rollup tables"),
+	}
+}
+
+// options returns the SQL options the flags select.
+func (f tableFlags) options() []sql.Option {
+	var opts []sql.Option
+	if *f.wattHours {
+		opts = append(opts, sql.WithWattHours())
+	}
+	if *f.schema != "" {
+		opts = append(opts, sql.WithSchema(*f.schema))
+	}
+	if *f.tablePrefix != "" {
+		opts = append(opts, sql.WithTablePrefix(*f.tablePrefix))
+	}
+	if *f.tableSuffix != "" {
+		opts = append(opts, sql.WithTableSuffix(*f.tableSuffix))
+	}
+	return opts
+}
+
+// newFlagSet returns the flag set of a command, with a usage message that
+// describes the command before its flags.
+func newFlagSet(name, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags]\n\n%s\n\nFlags:\n", programName(), name, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flo_energy_take_home/csv"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// runInspect prints the readings of one NMI, optionally narrowed to a suffix and day.
+func runInspect(args []string) error {
+	fs := newFlagSet("inspect", "Prints the readings of one NMI in a NEM12 file.")
+	filename := fs.String("file", "", "CSV file to read")
+	nmi := fs.String("nmi", "", "NMI to print the readings of (required)")
+	suffix := fs.String("suffix", "", "Only print readings for this NMI suffix")
+	date := fs.String("date", "", "Only print the intervals of this day, as YYYY-MM-DD in NEM time")
+	fs.Parse(args)
+
+	if *nmi == "" {
+		fs.Usage()
+		return errors.New("error: --nmi is required")
+	}
+	if *date != "" {
+		if _, err := time.Parse("2006-01-02", *date); err != nil {
+			return fmt.Errorf("error: --date must be YYYY-MM-DD: %v", err)
+		}
+	}
+
+	readings, err := readFile(filename, fs.Usage)
+	if err != nil {
+		return err
+	}
+
+	var matches []csv.Reading
+	for _, reading := range readings {
+		if reading.Nmi != *nmi || (*suffix != "" && reading.NmiSuffix != *suffix) {
+			continue
+		}
+		intervalDay := csv.ReadingDay(reading.Timestamp).Format("2006-01-02")
+		if *date != "" && intervalDay != *date {
+			continue
+		}
+		matches = append(matches, reading)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no readings found for NMI %s", *nmi)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].NmiSuffix != matches[j].NmiSuffix {
+			return matches[i].NmiSuffix < matches[j].NmiSuffix
+		}
+		return matches[i].Timestamp.Before(matches[j].Timestamp)
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUFFIX\tINTERVAL END\tCONSUMPTION\tUOM\tQUALITY")
+	for _, reading := range matches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", reading.NmiSuffix, reading.Timestamp.Format(time.RFC3339),
+			reading.Consumption, reading.Uom, reading.QualityMethod)
+	}
+	return w.Flush()
+}
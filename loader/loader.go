@@ -0,0 +1,189 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	gensql "flo_energy_take_home/sql"
+	"fmt"
+	"net"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	defaultMaxRetries  = 5
+	defaultBaseBackoff = 200 * time.Millisecond
+	maxBackoff         = 10 * time.Second
+)
+
+// Config controls how batches are loaded. Zero values use the defaults.
+type Config struct {
+	// Workers is the number of batches loaded concurrently, each in its own
+	// transaction. It defaults to the number of CPUs.
+	Workers int
+	// MaxRetries is how many times a batch is retried after a transient error.
+	MaxRetries int
+	// BaseBackoff is the wait before the first retry. It doubles on each retry.
+	BaseBackoff time.Duration
+}
+
+// Summary describes a completed load.
+type Summary struct {
+	Batches  int
+	Rows     int
+	Retries  int
+	Duration time.Duration
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("loaded %d rows in %d batches (%d retries) in %.2fs", s.Rows, s.Batches, s.Retries, s.Duration.Seconds())
+}
+
+// Open connects to Postgres with a pool sized for the given number of workers.
+func Open(dsn string, workers int) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	db.SetMaxOpenConns(workers)
+	db.SetMaxIdleConns(workers)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to database: %v", err)
+	}
+	return db, nil
+}
+
+// Load executes each batch in its own transaction, using up to cfg.Workers
+// transactions at once. A batch with Args runs as a parameterized statement.
+// Transient errors are retried with exponential backoff; any other error stops
+// the load and is returned.
+func Load(ctx context.Context, db *sql.DB, batches []gensql.Batch, cfg Config) (Summary, error) {
+	start := time.Now()
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workChan := make(chan int, len(batches))
+	errChan := make(chan error, len(batches))
+	var retries, rows int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range workChan {
+				attempts, err := loadWithRetry(ctx, db, batches[index], cfg)
+				atomic.AddInt64(&retries, int64(attempts-1))
+				if err != nil {
+					errChan <- fmt.Errorf("failed to load batch %d: %v", index+1, err)
+					cancel()
+					return
+				}
+				atomic.AddInt64(&rows, int64(batches[index].Rows))
+			}
+		}()
+	}
+
+	for i := range batches {
+		workChan <- i
+	}
+	close(workChan)
+
+	wg.Wait()
+	close(errChan)
+
+	summary := Summary{
+		Batches:  len(batches),
+		Rows:     int(rows),
+		Retries:  int(retries),
+		Duration: time.Since(start),
+	}
+	if err := <-errChan; err != nil {
+		return summary, err // Return the first error encountered
+	}
+	return summary, nil
+}
+
+// loadWithRetry runs a batch until it commits, fails with a non-transient
+// error or runs out of retries. It returns the number of attempts made.
+func loadWithRetry(ctx context.Context, db *sql.DB, batch gensql.Batch, cfg Config) (int, error) {
+	backoff := cfg.BaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := loadBatch(ctx, db, batch)
+		if err == nil || !isTransient(err) || attempt > cfg.MaxRetries {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func loadBatch(ctx context.Context, db *sql.DB, batch gensql.Batch) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, batch.Statement, batch.Args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isTransient reports whether err is worth retrying: a dropped connection, or
+// a Postgres error that says the same statement may succeed if run again.
+func isTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		code := pgErr.SQLState()
+		switch {
+		case len(code) == 5 && code[:2] == "08": // connection exception
+			return true
+		case code == "40001", code == "40P01": // serialization failure, deadlock
+			return true
+		case code == "53300", code == "57P01", code == "57P02", code == "57P03": // too many connections, shutdown, starting up
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	gensql "flo_energy_take_home/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pgError mimics the SQLState method Postgres drivers expose on their errors.
+type pgError struct{ code string }
+
+func (e pgError) Error() string    { return "pg error " + e.code }
+func (e pgError) SQLState() string { return e.code }
+
+// fakeDriver is a database/sql driver that records committed statements and
+// fails statements according to failures, keyed by statement.
+type fakeDriver struct {
+	mu        sync.Mutex
+	failures  map[string][]error
+	committed []string
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{driver: d}, nil }
+
+func (d *fakeDriver) nextError(query string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	errs := d.failures[query]
+	if len(errs) == 0 {
+		return nil
+	}
+	d.failures[query] = errs[1:]
+	return errs[0]
+}
+
+type fakeConn struct {
+	driver  *fakeDriver
+	pending []string
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return &fakeTx{conn: c}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.driver.nextError(query); err != nil {
+		return nil, err
+	}
+	c.pending = append(c.pending, query)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{ conn *fakeConn }
+
+func (tx *fakeTx) Commit() error {
+	tx.conn.driver.mu.Lock()
+	defer tx.conn.driver.mu.Unlock()
+	tx.conn.driver.committed = append(tx.conn.driver.committed, tx.conn.pending...)
+	tx.conn.pending = nil
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.conn.pending = nil
+	return nil
+}
+
+var registerOnce sync.Once
+var currentDriver = &switchDriver{}
+
+// switchDriver lets each test use a fresh fakeDriver under one registered name.
+type switchDriver struct {
+	mu     sync.Mutex
+	driver *fakeDriver
+}
+
+func (s *switchDriver) Open(name string) (driver.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.driver.Open(name)
+}
+
+func openFake(t *testing.T, failures map[string][]error) (*sql.DB, *fakeDriver) {
+	registerOnce.Do(func() { sql.Register("fake", currentDriver) })
+	fake := &fakeDriver{failures: failures}
+	currentDriver.mu.Lock()
+	currentDriver.driver = fake
+	currentDriver.mu.Unlock()
+
+	db, err := sql.Open("fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+func TestLoad(t *testing.T) {
+	batches := []gensql.Batch{
+		{Statement: "INSERT 1", Rows: 10},
+		{Statement: "INSERT 2", Rows: 20},
+		{Statement: "INSERT 3", Rows: 30},
+	}
+	cfg := Config{Workers: 2, MaxRetries: 2, BaseBackoff: time.Millisecond}
+
+	tests := []struct {
+		name            string
+		failures        map[string][]error
+		expectError     string
+		expectedRetries int
+		expectedRows    int
+	}{
+		{
+			name:         "Happy path",
+			expectedRows: 60,
+		},
+		{
+			name: "Transient errors are retried",
+			failures: map[string][]error{
+				"INSERT 2": {pgError{"40001"}, driver.ErrBadConn},
+			},
+			expectedRetries: 2,
+			expectedRows:    60,
+		},
+		{
+			name: "Permanent error is not retried",
+			failures: map[string][]error{
+				"INSERT 2": {pgError{"23505"}},
+			},
+			expectError: "failed to load batch 2: pg error 23505",
+		},
+		{
+			name: "Retries are limited",
+			failures: map[string][]error{
+				"INSERT 3": {pgError{"40P01"}, pgError{"40P01"}, pgError{"40P01"}},
+			},
+			expectError: "failed to load batch 3: pg error 40P01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := openFake(t, tt.failures)
+
+			summary, err := Load(context.Background(), db, batches, cfg)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing '%s', but got: %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if summary.Rows != tt.expectedRows || summary.Batches != len(batches) {
+				t.Errorf("Unexpected summary: %+v", summary)
+			}
+			if summary.Retries != tt.expectedRetries {
+				t.Errorf("Expected %d retries, but got %d", tt.expectedRetries, summary.Retries)
+			}
+			if len(fake.committed) != len(batches) {
+				t.Errorf("Expected %d committed statements, but got %v", len(batches), fake.committed)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{driver.ErrBadConn, true},
+		{pgError{"08006"}, true},
+		{pgError{"40001"}, true},
+		{pgError{"57P01"}, true},
+		{pgError{"23505"}, false},
+		{pgError{"42P01"}, false},
+		{errors.New("syntax error"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.expected {
+			t.Errorf("isTransient(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
@@ -1,50 +1,86 @@
 package main
 
 import (
-	"flag"
 	"flo_energy_take_home/csv"
-	"flo_energy_take_home/sql"
 	"flo_energy_take_home/util"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"path/filepath"
+	"strings"
 )
 
-func main() {
-	start := time.Now()
-	filename := flag.String("file", "", "CSV file to read")
-	batchSize := flag.Int("batch", 10000, "Number of sql files to produce")
-	//_ = flag.String("delimiter", ",", "CSV delimiter")
+// diagnostics receives progress and error messages.
+var diagnostics io.Writer = os.Stdout
 
-	flag.Parse()
+// command is a subcommand of the CLI, run with the arguments that follow its name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	err := util.ValidateFile(filename)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+var commands = []command{
+	{"convert", "Convert a NEM12 file to SQL or another output format (the default)", runConvert},
+	{"validate", "Check a NEM12 file without writing any output", runValidate},
+	{"stats", "Summarise the readings in a NEM12 file", runStats},
+	{"ddl", "Print the DDL for the target tables", runDDL},
+	{"inspect", "Print the readings of one NMI", runInspect},
+}
+
+func main() {
+	// Without a command, the arguments are convert's flags, as before there were commands
+	name, args := "convert", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	file, err := openFile(filename)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if name == "help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				fmt.Fprintln(diagnostics, err)
+				os.Exit(1)
+			}
+			return
+		}
 	}
-	defer file.Close()
 
-	readings, err := csv.ParallelProcessNEM12File(file)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+// usage lists the commands.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", programName())
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", programName())
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
 
-	statements, _ := sql.GenerateInsertStatements(readings, *batchSize)
+// readFile validates, opens and parses the NEM12 file named by filename. usage
+// prints the command's help when no file is given.
+func readFile(filename *string, usage func()) ([]csv.Reading, error) {
+	if err := util.ValidateFile(filename, usage); err != nil {
+		return nil, err
+	}
 
-	err = util.WriteToSQLFilesParallel(statements, "./out")
+	file, err := openFile(filename)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, err
 	}
-	fmt.Printf("%.2fs elapsed\n", time.Since(start).Seconds())
+	defer file.Close()
+
+	return csv.ParallelProcessNEM12File(file)
 }
 
 func openFile(filename *string) (*os.File, error) {
@@ -61,7 +97,7 @@ func openFile(filename *string) (*os.File, error) {
 	}
 
 	// Print file size
-	fmt.Printf("File size: %vB\n", info.Size())
+	fmt.Fprintf(diagnostics, "File size: %vB\n", info.Size())
 
 	return file, nil
 }
@@ -0,0 +1,81 @@
+// Package rollup lists the hourly, daily and monthly periods that interval
+// readings fall in, per NMI and suffix, for their totals to be recomputed.
+package rollup
+
+import (
+	"flo_energy_take_home/csv"
+	"sort"
+	"time"
+)
+
+// Period is an NMI and suffix over one rollup period, with the number of
+// intervals expected in it.
+type Period struct {
+	Nmi               string
+	NmiSuffix         string
+	Start             time.Time
+	ExpectedIntervals int32
+}
+
+// Hourly returns each hour the readings cover, per NMI and suffix.
+func Hourly(readings []csv.Reading) []Period {
+	return periods(readings, func(end time.Time) (time.Time, time.Time) {
+		// An interval ending on the hour belongs to the hour before
+		t := end.Add(-time.Nanosecond)
+		start := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		return start, start.Add(time.Hour)
+	})
+}
+
+// Daily returns each day the readings cover, per NMI and suffix.
+func Daily(readings []csv.Reading) []Period {
+	return periods(readings, func(end time.Time) (time.Time, time.Time) {
+		start := csv.ReadingDay(end)
+		return start, start.AddDate(0, 0, 1)
+	})
+}
+
+// Monthly returns each month the readings cover, per NMI and suffix.
+func Monthly(readings []csv.Reading) []Period {
+	return periods(readings, func(end time.Time) (time.Time, time.Time) {
+		day := csv.ReadingDay(end)
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, 0)
+	})
+}
+
+// periods returns the distinct periods returned by period, which gives the
+// start and end of the period an interval ending at a time belongs to. Periods
+// are sorted by NMI, suffix and start.
+func periods(readings []csv.Reading, period func(time.Time) (time.Time, time.Time)) []Period {
+	seen := make(map[string]bool)
+	var result []Period
+
+	for _, reading := range readings {
+		start, end := period(reading.Timestamp)
+		key := reading.Nmi + "|" + reading.NmiSuffix + "|" + start.Format(time.RFC3339)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		p := Period{Nmi: reading.Nmi, NmiSuffix: reading.NmiSuffix, Start: start}
+		if reading.IntervalLength > 0 {
+			p.ExpectedIntervals = int32(end.Sub(start) / (time.Duration(reading.IntervalLength) * time.Minute))
+		}
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Nmi != b.Nmi {
+			return a.Nmi < b.Nmi
+		}
+		if a.NmiSuffix != b.NmiSuffix {
+			return a.NmiSuffix < b.NmiSuffix
+		}
+		return a.Start.Before(b.Start)
+	})
+
+	return result
+}
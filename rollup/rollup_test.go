@@ -0,0 +1,56 @@
+package rollup
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"testing"
+	"time"
+)
+
+func halfHourlyReadings(nmi string, from time.Time, count int) []csv.Reading {
+	readings := make([]csv.Reading, count)
+	for i := range readings {
+		readings[i] = csv.Reading{
+			MeterReadings: model.MeterReadings{
+				Nmi:       nmi,
+				NmiSuffix: "E1",
+				Timestamp: from.Add(time.Duration(i+1) * 30 * time.Minute),
+			},
+			IntervalLength: 30,
+		}
+	}
+	return readings
+}
+
+func TestPeriods(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	// A full day and the first 12 intervals of the next, plus one day of a second NMI
+	readings := halfHourlyReadings("NMI2", march1, 48)
+	readings = append(readings, halfHourlyReadings("NMI1", march1, 60)...)
+
+	hourly := Hourly(readings)
+	// NMI1 covers 30 hours and NMI2 24
+	if len(hourly) != 54 {
+		t.Fatalf("Expected 54 hours, but got %d", len(hourly))
+	}
+	if hourly[0].Nmi != "NMI1" || !hourly[0].Start.Equal(march1) || hourly[0].ExpectedIntervals != 2 {
+		t.Errorf("Expected periods sorted by NMI and start, but the first is %+v", hourly[0])
+	}
+
+	daily := Daily(readings)
+	if len(daily) != 3 {
+		t.Fatalf("Expected 3 days, but got %d", len(daily))
+	}
+	// The interval ending at midnight on 2 March belongs to 1 March
+	if !daily[1].Start.Equal(march1.AddDate(0, 0, 1)) || daily[1].ExpectedIntervals != 48 {
+		t.Errorf("Unexpected period for a partial day: %+v", daily[1])
+	}
+
+	monthly := Monthly(readings)
+	if len(monthly) != 2 {
+		t.Fatalf("Expected 2 months, but got %d", len(monthly))
+	}
+	if monthly[0].ExpectedIntervals != 31*48 {
+		t.Errorf("Unexpected monthly period: %+v", monthly[0])
+	}
+}
@@ -0,0 +1,34 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/util"
+)
+
+func init() {
+	Register("influx", newInfluxSink)
+}
+
+// influxSink writes InfluxDB line protocol files on Close, chunked by
+// Config.BatchSize like the SQL files.
+type influxSink struct {
+	cfg      Config
+	readings []csv.Reading
+}
+
+func newInfluxSink(cfg Config) (Sink, error) {
+	return &influxSink{cfg: cfg}, nil
+}
+
+func (s *influxSink) Open() error {
+	return nil
+}
+
+func (s *influxSink) Write(readings []csv.Reading) error {
+	s.readings = append(s.readings, readings...)
+	return nil
+}
+
+func (s *influxSink) Close() error {
+	return util.WriteToLineProtocolFilesParallel(s.readings, s.cfg.BatchSize, s.cfg.OutputDir)
+}
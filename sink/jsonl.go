@@ -0,0 +1,50 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/util"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	Register("jsonl", newJSONLinesSink)
+}
+
+// jsonLinesSink writes each batch of readings to a new JSON Lines file, with a
+// record per reading, or per NMI, suffix and day with the days layout.
+type jsonLinesSink struct {
+	cfg Config
+	seq int
+}
+
+func newJSONLinesSink(cfg Config) (Sink, error) {
+	return &jsonLinesSink{cfg: cfg}, nil
+}
+
+func (s *jsonLinesSink) Open() error {
+	if err := os.MkdirAll(s.cfg.OutputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return nil
+}
+
+func (s *jsonLinesSink) Write(readings []csv.Reading) error {
+	s.seq++
+	fileName := filepath.Join(s.cfg.OutputDir, fmt.Sprintf("part-%05d.jsonl", s.seq))
+	file, err := util.CreateAtomic(fileName, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := util.WriteJSONLines(file, readings, s.cfg.Layout == "days"); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", fileName, err)
+	}
+	return file.Commit()
+}
+
+func (s *jsonLinesSink) Close() error {
+	return nil
+}
@@ -0,0 +1,154 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/sql"
+	"flo_energy_take_home/util"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+func init() {
+	Register("parquet", newParquetSink)
+}
+
+// consumptionScale is the number of decimal places of kWh kept in Parquet.
+const consumptionScale = 6
+
+var plainNMI = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// parquetReading is the Parquet schema of a reading. Timestamps are stored as
+// UTC instants, converted from the NEM time of the file, and consumption as a
+// decimal number of kWh, converted from the channel's unit of measure.
+type parquetReading struct {
+	Nmi            string `parquet:"name=nmi, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	NmiSuffix      string `parquet:"name=nmi_suffix, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Timestamp      int64  `parquet:"name=timestamp, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS"`
+	Consumption    int64  `parquet:"name=consumption, type=INT64, convertedtype=DECIMAL, scale=6, precision=18"`
+	IntervalLength int32  `parquet:"name=interval_length, type=INT32"`
+	QualityMethod  string `parquet:"name=quality_method, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+}
+
+// parquetSink writes each batch of readings to a new Parquet file, with a row
+// group per Config.BatchSize readings. With Config.ParquetPartitions, the
+// readings are split into nmi=/date= directories instead.
+type parquetSink struct {
+	cfg Config
+	seq int
+}
+
+func newParquetSink(cfg Config) (Sink, error) {
+	return &parquetSink{cfg: cfg}, nil
+}
+
+func (s *parquetSink) Open() error {
+	if err := os.MkdirAll(s.cfg.OutputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return nil
+}
+
+func (s *parquetSink) Write(readings []csv.Reading) error {
+	s.seq++
+	name := fmt.Sprintf("part-%05d.parquet", s.seq)
+	if !s.cfg.ParquetPartitions {
+		return writeParquetFile(filepath.Join(s.cfg.OutputDir, name), readings, s.cfg.BatchSize)
+	}
+
+	partitions := make(map[string][]csv.Reading)
+	for _, reading := range readings {
+		if !plainNMI.MatchString(reading.Nmi) {
+			return fmt.Errorf("NMI %q can't be used as a partition directory", reading.Nmi)
+		}
+		date := csv.ReadingDay(reading.Timestamp).Format("2006-01-02")
+		dir := filepath.Join("nmi="+reading.Nmi, "date="+date)
+		partitions[dir] = append(partitions[dir], reading)
+	}
+
+	dirs := make([]string, 0, len(partitions))
+	for dir := range partitions {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		path := filepath.Join(s.cfg.OutputDir, dir)
+		if err := os.MkdirAll(path, os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create partition directory: %v", err)
+		}
+		if err := writeParquetFile(filepath.Join(path, name), partitions[dir], s.cfg.BatchSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *parquetSink) Close() error {
+	return nil
+}
+
+// writeParquetFile writes readings to a Snappy compressed Parquet file, starting
+// a new row group every rowGroupSize readings.
+func writeParquetFile(fileName string, readings []csv.Reading, rowGroupSize int) error {
+	file, err := util.CreateAtomic(fileName, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	pw, err := writer.NewParquetWriterFromWriter(file, new(parquetReading), int64(runtime.NumCPU()))
+	if err != nil {
+		return fmt.Errorf("failed to create parquet writer: %v", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	// Row groups are cut by reading count below, never by size
+	pw.RowGroupSize = math.MaxInt64
+
+	for i, reading := range readings {
+		row, err := toParquetReading(reading)
+		if err != nil {
+			return err
+		}
+		if err := pw.Write(row); err != nil {
+			return fmt.Errorf("failed to write reading to %s: %v", fileName, err)
+		}
+		if rowGroupSize > 0 && (i+1)%rowGroupSize == 0 {
+			if err := pw.Flush(true); err != nil {
+				return fmt.Errorf("failed to write row group to %s: %v", fileName, err)
+			}
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish %s: %v", fileName, err)
+	}
+	return file.Commit()
+}
+
+func toParquetReading(reading csv.Reading) (parquetReading, error) {
+	kwh, err := sql.KilowattHours(reading)
+	if err != nil {
+		return parquetReading{}, err
+	}
+	consumption := kwh.Shift(consumptionScale)
+	if !consumption.IsInteger() {
+		return parquetReading{}, fmt.Errorf("consumption %s kWh has more than %d decimal places", kwh, consumptionScale)
+	}
+
+	return parquetReading{
+		Nmi:            reading.Nmi,
+		NmiSuffix:      reading.NmiSuffix,
+		Timestamp:      csv.InNEMTime(reading.Timestamp).UnixMilli(),
+		Consumption:    consumption.IntPart(),
+		IntervalLength: reading.IntervalLength,
+		QualityMethod:  reading.QualityMethod,
+	}, nil
+}
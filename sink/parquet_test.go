@@ -0,0 +1,145 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func parquetTestReadings() []csv.Reading {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	var readings []csv.Reading
+	for _, nmi := range []string{"NMI1", "NMI2"} {
+		for i := 1; i <= 48; i++ {
+			readings = append(readings, csv.Reading{
+				MeterReadings: model.MeterReadings{
+					Nmi:         nmi,
+					NmiSuffix:   "E1",
+					Timestamp:   march1.Add(time.Duration(i) * 30 * time.Minute),
+					Consumption: decimal.RequireFromString("0.461"),
+				},
+				IntervalLength: 30,
+				QualityMethod:  "A",
+				Uom:            "kWh",
+			})
+		}
+	}
+	return readings
+}
+
+func TestParquetSink(t *testing.T) {
+	dir := t.TempDir()
+	out, err := New("parquet", Config{OutputDir: dir, BatchSize: 40})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	readings := parquetTestReadings()
+	if err := WriteAll(out, readings); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	file, err := local.NewLocalFileReader(filepath.Join(dir, "part-00001.parquet"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer file.Close()
+	pr, err := reader.NewParquetReader(file, new(parquetReading), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer pr.ReadStop()
+
+	if pr.GetNumRows() != 96 {
+		t.Errorf("Expected 96 rows, but got %d", pr.GetNumRows())
+	}
+	// 96 readings in row groups of 40
+	if len(pr.Footer.RowGroups) != 3 {
+		t.Errorf("Expected 3 row groups, but got %d", len(pr.Footer.RowGroups))
+	}
+
+	rows := make([]parquetReading, 1)
+	if err := pr.Read(&rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := parquetReading{
+		Nmi:            "NMI1",
+		NmiSuffix:      "E1",
+		Timestamp:      time.Date(2005, 2, 28, 14, 30, 0, 0, time.UTC).UnixMilli(),
+		Consumption:    461000,
+		IntervalLength: 30,
+		QualityMethod:  "A",
+	}
+	if rows[0] != expected {
+		t.Errorf("Expected %+v, but got %+v", expected, rows[0])
+	}
+}
+
+func TestParquetSinkPartitions(t *testing.T) {
+	dir := t.TempDir()
+	out, err := New("parquet", Config{OutputDir: dir, ParquetPartitions: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := WriteAll(out, parquetTestReadings()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The interval ending at midnight belongs to the day before
+	for _, nmi := range []string{"NMI1", "NMI2"} {
+		name := filepath.Join(dir, "nmi="+nmi, "date=2005-03-01", "part-00001.parquet")
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nmi=NMI1", "date=2005-03-02")); !os.IsNotExist(err) {
+		t.Errorf("Expected no partition for the day after, but got: %v", err)
+	}
+}
+
+func TestToParquetReadingPrecision(t *testing.T) {
+	reading := csv.Reading{MeterReadings: model.MeterReadings{Consumption: decimal.RequireFromString("0.1234567")}, Uom: "kWh"}
+	if _, err := toParquetReading(reading); err == nil {
+		t.Errorf("Expected an error for consumption with more than 6 decimal places")
+	}
+}
+
+func TestToParquetReadingUnits(t *testing.T) {
+	tests := []struct {
+		name        string
+		consumption string
+		uom         string
+		expected    int64
+		expectError bool
+	}{
+		{name: "kWh", consumption: "0.461", uom: "kWh", expected: 461000},
+		{name: "Wh", consumption: "461", uom: "Wh", expected: 461000},
+		{name: "MWh", consumption: "0.000461", uom: "MWH", expected: 461000},
+		{name: "Not energy", consumption: "0.461", uom: "kVArh", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading := csv.Reading{MeterReadings: model.MeterReadings{Consumption: decimal.RequireFromString(tt.consumption)}, Uom: tt.uom}
+			row, err := toParquetReading(reading)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %s, but got none", tt.uom)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if row.Consumption != tt.expected {
+				t.Errorf("Expected consumption %d, but got %d", tt.expected, row.Consumption)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/loader"
+	gensql "flo_energy_take_home/sql"
+	"fmt"
+)
+
+func init() {
+	Register("postgres", newPostgresSink)
+}
+
+// postgresSink loads each batch of readings straight into the database at
+// Config.DSN, as parameterized statements.
+type postgresSink struct {
+	cfg     Config
+	load    loader.Config
+	db      *sql.DB
+	summary loader.Summary
+}
+
+func newPostgresSink(cfg Config) (Sink, error) {
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("the postgres output format needs a --dsn")
+	}
+	return &postgresSink{cfg: cfg}, nil
+}
+
+func (s *postgresSink) Open() error {
+	db, err := loader.Open(s.cfg.DSN, s.load.Workers)
+	if err != nil {
+		return err
+	}
+	s.db = db
+	return nil
+}
+
+func (s *postgresSink) Write(readings []csv.Reading) error {
+	batches, err := gensql.GenerateParameterizedBatches(readings, s.cfg.BatchSize, s.cfg.SQLOptions...)
+	if err != nil {
+		return err
+	}
+	if err := s.loadBatches(batches); err != nil {
+		return err
+	}
+
+	// Rollups are recomputed from the loaded readings, so they can only start
+	// once every batch of readings is in
+	if s.cfg.Rollups {
+		rollups, err := gensql.GenerateRollupBatches(readings, s.cfg.BatchSize, s.cfg.SQLOptions...)
+		if err != nil {
+			return err
+		}
+		return s.loadBatches(rollups)
+	}
+	return nil
+}
+
+// loadBatches loads batches concurrently, adding them to the summary.
+func (s *postgresSink) loadBatches(batches []gensql.Batch) error {
+	summary, err := loader.Load(context.Background(), s.db, batches, s.load)
+	s.summary.Batches += summary.Batches
+	s.summary.Rows += summary.Rows
+	s.summary.Retries += summary.Retries
+	s.summary.Duration += summary.Duration
+	return err
+}
+
+// Close prints a summary of everything loaded and closes the connection pool.
+func (s *postgresSink) Close() error {
+	fmt.Println(s.summary)
+	return s.db.Close()
+}
@@ -0,0 +1,103 @@
+// Package sink defines where parsed readings are written, and the registry of
+// output formats that --output-format selects from.
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sink writes batches of readings to an output format or destination.
+type Sink interface {
+	// Open prepares the output before any readings are written.
+	Open() error
+	// Write writes a batch of readings. It may be called any number of times.
+	Write(readings []csv.Reading) error
+	// Close finishes the output, committing anything still buffered.
+	Close() error
+}
+
+// WriteAll opens the sink, writes the readings and closes it. The sink is closed
+// even if the write fails.
+func WriteAll(out Sink, readings []csv.Reading) error {
+	if err := out.Open(); err != nil {
+		return err
+	}
+	if err := out.Write(readings); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Config is the run configuration a sink is created from. Sinks ignore the
+// settings that do not apply to them.
+type Config struct {
+	// OutputDir is the directory file sinks write into.
+	OutputDir string
+	// Out is a single file for SQL output to be written to instead of
+	// OutputDir, or "-" for stdout. It must not exist unless Overwrite is set.
+	Out       string
+	Overwrite bool
+	// DSN is the Postgres connection string for sinks that load a database.
+	DSN string
+	// BatchSize is the maximum number of readings per statement or file.
+	BatchSize int
+	// SQLOptions configure the generated SQL.
+	SQLOptions []sql.Option
+	// Layout is the table layout, "rows" or "days".
+	Layout string
+	// Rollups adds hourly, daily and monthly totals to SQL output.
+	Rollups bool
+	// RunID identifies the run, and Source is the name of the input file
+	// without its extension, for naming output files.
+	RunID  string
+	Source string
+	// FileNameTemplate names SQL statement files, see util.FileNameTemplate.
+	// Empty means util.DefaultFileNameTemplate.
+	FileNameTemplate string
+	// Compression compresses SQL statement files, "gzip" or "zstd", at
+	// CompressionLevel, where 0 means the format's default level.
+	Compression      string
+	CompressionLevel int
+	// ParquetPartitions splits Parquet output into nmi=/date= directories.
+	ParquetPartitions bool
+	// SplitByNMI writes CSV output to a file per NMI.
+	SplitByNMI bool
+}
+
+// Factory creates a sink from the run configuration.
+type Factory func(cfg Config) (Sink, error)
+
+var registry = make(map[string]Factory)
+
+// Register makes a sink available under name. It is meant to be called from the
+// init function of the file that implements the sink.
+func Register(name string, factory Factory) {
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("sink %s registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// New creates the sink registered under name.
+func New(name string, cfg Config) (Sink, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, must be one of %s", name, strings.Join(Formats(), ", "))
+	}
+	return factory(cfg)
+}
+
+// Formats returns the names of the registered sinks, sorted.
+func Formats() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,105 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		cfg            Config
+		expectError    bool
+		errorSubstring string
+	}{
+		{name: "SQL files", format: "sql"},
+		{name: "Postgres without a DSN", format: "postgres", expectError: true, errorSubstring: "--dsn"},
+		{name: "Unknown format", format: "xml", expectError: true, errorSubstring: "unknown output format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.format, tt.cfg)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, but got none")
+				} else if !strings.Contains(err.Error(), tt.errorSubstring) {
+					t.Errorf("Expected error containing '%s', but got: %v", tt.errorSubstring, err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLFileSink(t *testing.T) {
+	dir := t.TempDir()
+	out, err := New("sql", Config{OutputDir: dir, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.5")}},
+	}
+	if err := out.Open(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Each write adds to the same run, so the files are numbered across writes
+	for i := 0; i < 2; i++ {
+		if err := out.Write(readings); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"statement_1.sql", "statement_2.sql", "manifest.json", "load.sh"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+}
+
+func TestSQLFileSinkSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "readings.sql")
+	out, err := New("sql", Config{Out: fileName, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.5")}},
+		{MeterReadings: model.MeterReadings{Nmi: "NMI2", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("2.5")}},
+	}
+	if err := WriteAll(out, readings); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Count(string(content), "BEGIN;") != 2 || strings.Index(string(content), "'NMI1'") > strings.Index(string(content), "'NMI2'") {
+		t.Errorf("Expected both batches in order in one file:\n%s", content)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the single file, but got %d files", len(entries))
+	}
+}
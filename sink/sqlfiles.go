@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/sql"
+	"flo_energy_take_home/util"
+	"os"
+)
+
+func init() {
+	Register("sql", newSQLFileSink)
+}
+
+// sqlFileSink writes statement files, with a manifest and load.sh, on Close. With
+// Config.Out, it writes every statement to that one file, or stdout, instead.
+// Nothing is written if any write failed.
+type sqlFileSink struct {
+	cfg     Config
+	batches []sql.Batch
+	rollups []sql.Batch
+	failed  bool
+}
+
+func newSQLFileSink(cfg Config) (Sink, error) {
+	return &sqlFileSink{cfg: cfg}, nil
+}
+
+func (s *sqlFileSink) Open() error {
+	return nil
+}
+
+func (s *sqlFileSink) Write(readings []csv.Reading) error {
+	if err := s.generate(readings); err != nil {
+		s.failed = true
+		return err
+	}
+	return nil
+}
+
+func (s *sqlFileSink) generate(readings []csv.Reading) error {
+	generate := sql.GenerateInsertBatches
+	if s.cfg.Layout == "days" {
+		generate = sql.GenerateDayArrayBatches
+	}
+	batches, err := generate(readings, s.cfg.BatchSize, s.cfg.SQLOptions...)
+	if err != nil {
+		return err
+	}
+	s.batches = append(s.batches, batches...)
+
+	if s.cfg.Rollups {
+		opts := s.cfg.SQLOptions
+		if s.cfg.Layout == "days" {
+			opts = append(opts[:len(opts):len(opts)], sql.WithDayArrays())
+		}
+		rollups, err := sql.GenerateRollupBatches(readings, s.cfg.BatchSize, opts...)
+		if err != nil {
+			return err
+		}
+		s.rollups = append(s.rollups, rollups...)
+	}
+	return nil
+}
+
+// Close writes the files, or the single stream, with the rollups after every
+// reading batch. With a staging merge, the reading batches come between the
+// one that opens the staging table and the one that merges it.
+func (s *sqlFileSink) Close() error {
+	if s.failed {
+		return nil
+	}
+	open, merge := sql.GenerateStagingBatches(s.cfg.SQLOptions...)
+	batches := append(open, s.batches...)
+	batches = append(batches, merge...)
+	batches = append(batches, s.rollups...)
+	switch s.cfg.Out {
+	case "":
+	case "-":
+		return util.WriteSQLStream(os.Stdout, batches)
+	default:
+		return util.WriteSQLFile(batches, s.cfg.Out, s.cfg.Overwrite)
+	}
+
+	var opts []util.WriteOption
+	if s.cfg.FileNameTemplate != "" {
+		template, err := util.ParseFileNameTemplate(s.cfg.FileNameTemplate)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, util.WithFileNames(template, s.cfg.RunID, s.cfg.Source))
+	}
+	if s.cfg.Compression != "" {
+		opts = append(opts, util.WithCompression(s.cfg.Compression, s.cfg.CompressionLevel))
+	}
+	return util.WriteToSQLFilesParallel(batches, s.cfg.OutputDir, opts...)
+}
@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func init() {
+	Register("csv", newTidyCSVSink)
+}
+
+// tidyCSVFileName is the file the csv sink writes in Config.OutputDir, unless
+// it is split per NMI.
+const tidyCSVFileName = "readings.csv"
+
+// tidyCSVSink streams readings to long-format CSV as they are written. With
+// Config.SplitByNMI, each NMI gets its own <nmi>.csv file instead. Files are
+// streamed to hidden temporary files, which Close renames into place unless a
+// write failed.
+type tidyCSVSink struct {
+	cfg Config
+	// temps maps each file written to the temporary file it is streamed to
+	temps  map[string]string
+	failed bool
+}
+
+func newTidyCSVSink(cfg Config) (Sink, error) {
+	return &tidyCSVSink{cfg: cfg, temps: make(map[string]string)}, nil
+}
+
+func (s *tidyCSVSink) Open() error {
+	if err := os.MkdirAll(s.cfg.OutputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return nil
+}
+
+func (s *tidyCSVSink) Write(readings []csv.Reading) error {
+	if err := s.write(readings); err != nil {
+		s.failed = true
+		return err
+	}
+	return nil
+}
+
+func (s *tidyCSVSink) write(readings []csv.Reading) error {
+	if !s.cfg.SplitByNMI {
+		return s.writeFile(tidyCSVFileName, readings)
+	}
+
+	// Keep each NMI's readings in the order they were written
+	var nmis []string
+	byNMI := make(map[string][]csv.Reading)
+	for _, reading := range readings {
+		if !plainNMI.MatchString(reading.Nmi) {
+			return fmt.Errorf("NMI %q can't be used as a file name", reading.Nmi)
+		}
+		if _, ok := byNMI[reading.Nmi]; !ok {
+			nmis = append(nmis, reading.Nmi)
+		}
+		byNMI[reading.Nmi] = append(byNMI[reading.Nmi], reading)
+	}
+
+	for _, nmi := range nmis {
+		if err := s.writeFile(nmi+".csv", byNMI[nmi]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile appends readings to the temporary file for name, starting it with
+// the header the first time it is written.
+func (s *tidyCSVSink) writeFile(name string, readings []csv.Reading) error {
+	temp, started := s.temps[name]
+	flags := os.O_WRONLY | os.O_APPEND
+	if !started {
+		temp = filepath.Join(s.cfg.OutputDir, "."+name+".tmp")
+		flags |= os.O_CREATE | os.O_TRUNC
+		s.temps[name] = temp
+	}
+
+	file, err := os.OpenFile(temp, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %v", temp, err)
+	}
+	defer file.Close()
+
+	if err := util.WriteTidyCSV(file, readings, !started); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", temp, err)
+	}
+	return file.Close()
+}
+
+// Close renames the temporary files into place, or removes them if a write failed.
+func (s *tidyCSVSink) Close() error {
+	names := make([]string, 0, len(s.temps))
+	for name := range s.temps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if s.failed {
+			os.Remove(s.temps[name])
+			continue
+		}
+		fileName := filepath.Join(s.cfg.OutputDir, name)
+		if err := os.Rename(s.temps[name], fileName); err != nil {
+			return fmt.Errorf("failed to write file %s: %v", fileName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTidyCSVSinkSplitByNMI(t *testing.T) {
+	dir := t.TempDir()
+	// A file left over from an earlier run is replaced, not appended to
+	if err := os.WriteFile(filepath.Join(dir, "NMI1.csv"), []byte("stale\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out, err := New("csv", Config{OutputDir: dir, SplitByNMI: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := out.Open(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	timestamp := time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC)
+	for _, nmi := range []string{"NMI1", "NMI2", "NMI1"} {
+		readings := []csv.Reading{{MeterReadings: model.MeterReadings{Nmi: nmi, NmiSuffix: "E1", Timestamp: timestamp, Consumption: decimal.RequireFromString("1")}, IntervalLength: 30}}
+		if err := out.Write(readings); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		file         string
+		expectedRows int
+	}{
+		{file: "NMI1.csv", expectedRows: 2},
+		{file: "NMI2.csv", expectedRows: 1},
+	}
+	for _, tt := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if lines[0] != "nmi,suffix,interval_start,interval_end,value,uom,quality" {
+			t.Errorf("Expected %s to start with the header, but got %s", tt.file, lines[0])
+		}
+		if len(lines)-1 != tt.expectedRows {
+			t.Errorf("Expected %d rows in %s, but got %d:\n%s", tt.expectedRows, tt.file, len(lines)-1, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "readings.csv")); !os.IsNotExist(err) {
+		t.Errorf("Expected no combined file when split by NMI, but got: %v", err)
+	}
+}
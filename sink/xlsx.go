@@ -0,0 +1,42 @@
+package sink
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/util"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	Register("xlsx", newXLSXSink)
+}
+
+// xlsxFileName is the workbook the xlsx sink writes in Config.OutputDir.
+const xlsxFileName = "readings.xlsx"
+
+// xlsxSink writes every reading to a single Excel workbook on Close.
+type xlsxSink struct {
+	cfg      Config
+	readings []csv.Reading
+}
+
+func newXLSXSink(cfg Config) (Sink, error) {
+	return &xlsxSink{cfg: cfg}, nil
+}
+
+func (s *xlsxSink) Open() error {
+	if err := os.MkdirAll(s.cfg.OutputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return nil
+}
+
+func (s *xlsxSink) Write(readings []csv.Reading) error {
+	s.readings = append(s.readings, readings...)
+	return nil
+}
+
+func (s *xlsxSink) Close() error {
+	return util.WriteXLSX(s.readings, filepath.Join(s.cfg.OutputDir, xlsxFileName))
+}
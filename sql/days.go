@@ -0,0 +1,162 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GenerateDayArrayBatches generates INSERT statements for the day array layout,
+// where each row holds one day of intervals for an NMI and suffix, with the
+// interval values and their quality methods in parallel arrays. A blank
+// interval is NULL in both. Days are never split across batches.
+func GenerateDayArrayBatches(readings []csv.Reading, batchSize int, opts ...Option) ([]Batch, error) {
+	o := newOptions(opts)
+	if o.deterministicIDs || o.stagingMerge || o.replace || o.maxStatementSize > 0 {
+		return nil, fmt.Errorf("the day array layout supports only the table, Wh and partition options")
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return generatePartitioned(readings, o, func(readings []csv.Reading) ([]Batch, error) {
+		return generateBatches(packGroups(groupByDay(readings), batchSize), func(batch []csv.Reading) (Batch, error) {
+			sql, err := generateDayArrayStatement(batch, o)
+			if err != nil {
+				return Batch{}, err
+			}
+			return newBatch(sql, batch), nil
+		})
+	})
+}
+
+func generateDayArrayStatement(batch []csv.Reading, o options) (string, error) {
+	groups := groupByDay(batch)
+	days := make([]model.MeterReadingDays, len(groups))
+	for i, group := range groups {
+		day, err := toReadingDay(group, o)
+		if err != nil {
+			return "", err
+		}
+		days[i] = day
+	}
+
+	meterReadingDays := o.meterReadingDaysTable()
+	stmt := meterReadingDays.INSERT(
+		meterReadingDays.Nmi,
+		meterReadingDays.NmiSuffix,
+		meterReadingDays.ReadingDate,
+		meterReadingDays.IntervalLength,
+		meterReadingDays.Consumption,
+		meterReadingDays.QualityMethod,
+	).MODELS(days)
+
+	onConflict := stmt.ON_CONFLICT(
+		meterReadingDays.Nmi,
+		meterReadingDays.NmiSuffix,
+		meterReadingDays.ReadingDate,
+	).DO_NOTHING()
+
+	sql, args := onConflict.Sql()
+	return inlineArgs(sql, args)
+}
+
+// GenerateDayArrayDDL returns the statements that create the day array table,
+// the unique constraint its inserts rely on, and a view that unnests it back
+// into one row per interval, in the shape of meter_readings. The view has no
+// stored IDs, so its id column is always NULL.
+func GenerateDayArrayDDL(opts ...Option) string {
+	o := newOptions(opts)
+	t := o.meterReadingDaysTable()
+	name := t.TableName()
+	qualified := qualifiedName(t.SchemaName(), name)
+
+	consumptionType := "numeric[]"
+	if o.wattHours {
+		consumptionType = "bigint[]"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", qualified)
+	b.WriteString("    id uuid NOT NULL DEFAULT gen_random_uuid(),\n")
+	b.WriteString("    nmi varchar(10) NOT NULL,\n")
+	b.WriteString("    nmi_suffix varchar(2) NOT NULL,\n")
+	b.WriteString("    reading_date date NOT NULL,\n")
+	b.WriteString("    interval_length integer NOT NULL,\n")
+	fmt.Fprintf(&b, "    consumption %s NOT NULL,\n", consumptionType)
+	b.WriteString("    quality_method varchar(3)[] NOT NULL,\n")
+	fmt.Fprintf(&b, "    CONSTRAINT %s PRIMARY KEY (id),\n", quoteIdentifier(name+"_pk"))
+	fmt.Fprintf(&b, "    CONSTRAINT %s UNIQUE (nmi, nmi_suffix, reading_date)\n", quoteIdentifier(name+"_unique_day"))
+	b.WriteString(");\n\n")
+
+	// Interval n of a day ends n interval lengths after midnight
+	fmt.Fprintf(&b, "CREATE OR REPLACE VIEW %s AS\n", qualifiedName(t.SchemaName(), name+"_unnested"))
+	b.WriteString("SELECT\n")
+	b.WriteString("    NULL::uuid AS id,\n")
+	b.WriteString("    d.nmi,\n")
+	b.WriteString("    d.nmi_suffix,\n")
+	b.WriteString("    d.reading_date + make_interval(mins => d.interval_length * i.n::integer) AS \"timestamp\",\n")
+	b.WriteString("    i.consumption\n")
+	fmt.Fprintf(&b, "FROM %s d\n", qualified)
+	b.WriteString("CROSS JOIN LATERAL unnest(d.consumption) WITH ORDINALITY AS i(consumption, n)\n")
+	b.WriteString("WHERE i.consumption IS NOT NULL;\n")
+
+	return b.String()
+}
+
+// toReadingDay builds the day array row for one NMI, suffix and day of readings.
+func toReadingDay(group []csv.Reading, o options) (model.MeterReadingDays, error) {
+	first := group[0]
+	if first.IntervalLength <= 0 {
+		return model.MeterReadingDays{}, fmt.Errorf("reading for %s on %s has no interval length", first.Nmi, first.Timestamp)
+	}
+	date := csv.ReadingDay(first.Timestamp)
+	length := time.Duration(first.IntervalLength) * time.Minute
+	numberOfIntervals := 1440 / int(first.IntervalLength)
+
+	values := make([]string, numberOfIntervals)
+	qualities := make([]string, numberOfIntervals)
+	for i := range values {
+		values[i] = "NULL"
+		qualities[i] = "NULL"
+	}
+
+	for _, reading := range group {
+		if reading.IntervalLength != first.IntervalLength {
+			return model.MeterReadingDays{}, fmt.Errorf("readings for %s on %s have mixed interval lengths", first.Nmi, date.Format("2006-01-02"))
+		}
+		// Timestamps mark the end of an interval, so the first interval ends one length in
+		slot := int(reading.Timestamp.Sub(date)/length) - 1
+		if slot < 0 || slot >= numberOfIntervals || reading.Timestamp.Sub(date)%length != 0 {
+			return model.MeterReadingDays{}, fmt.Errorf("reading for %s at %s is not on a %d minute interval boundary", reading.Nmi, reading.Timestamp, first.IntervalLength)
+		}
+		value := reading.Consumption.String()
+		if o.wattHours {
+			wh, err := toWattHours(reading)
+			if err != nil {
+				return model.MeterReadingDays{}, err
+			}
+			value = fmt.Sprint(wh)
+		}
+		values[slot] = value
+		qualities[slot] = `"` + strings.ReplaceAll(reading.QualityMethod, `"`, `\"`) + `"`
+	}
+
+	return model.MeterReadingDays{
+		Nmi:            first.Nmi,
+		NmiSuffix:      first.NmiSuffix,
+		ReadingDate:    date,
+		IntervalLength: first.IntervalLength,
+		Consumption:    "{" + strings.Join(values, ",") + "}",
+		QualityMethod:  "{" + strings.Join(qualities, ",") + "}",
+	}, nil
+}
+
+// groupByDay groups readings by NMI, suffix and day, in order of first appearance.
+func groupByDay(readings []csv.Reading) [][]csv.Reading {
+	return groupBy(readings, func(reading csv.Reading) string {
+		return reading.Nmi + "|" + reading.NmiSuffix + "|" + csv.ReadingDay(reading.Timestamp).Format("2006-01-02")
+	})
+}
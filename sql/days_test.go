@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGenerateDayArrayBatches(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	var readings []csv.Reading
+	for _, day := range []time.Time{march1, march1.AddDate(0, 0, 1)} {
+		for _, reading := range dayOfReadings("NMI1", "E1", day) {
+			reading.IntervalLength = 30
+			reading.QualityMethod = "A"
+			readings = append(readings, reading)
+		}
+	}
+	// Drop the second interval of the first day, as a blank interval would be
+	readings = append(readings[:1], readings[2:]...)
+	readings[0].Consumption = decimal.RequireFromString("1.25")
+
+	batches, err := GenerateDayArrayBatches(readings, 48)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("Expected a batch per day, but got %d", len(batches))
+	}
+
+	first := batches[0].Statement
+	for _, expected := range []string{
+		"INSERT INTO public.meter_reading_days",
+		"'2005-03-01 00:00:00'",
+		"'{1.25,NULL,0.5,",
+		`'{"A",NULL,"A",`,
+		"ON CONFLICT (nmi, nmi_suffix, reading_date) DO NOTHING",
+	} {
+		if !strings.Contains(first, expected) {
+			t.Errorf("SQL doesn't contain %s:\n%.500s", expected, first)
+		}
+	}
+	if batches[0].Rows != 47 {
+		t.Errorf("Expected the first batch to cover 47 readings, but got %d", batches[0].Rows)
+	}
+	if strings.Count(first, ",") != strings.Count(batches[1].Statement, ",") {
+		t.Errorf("Expected every day array to have 48 elements")
+	}
+
+	wattHours, err := GenerateDayArrayBatches(readings, 48, WithWattHours())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(wattHours[0].Statement, "'{1250,NULL,500,") {
+		t.Errorf("SQL doesn't contain consumption as whole Wh:\n%.500s", wattHours[0].Statement)
+	}
+
+	if _, err := GenerateDayArrayBatches(readings, 48, WithReplace()); err == nil {
+		t.Errorf("Expected an error for replace mode with the day array layout")
+	}
+}
+
+func TestGenerateDayArrayDDL(t *testing.T) {
+	ddl := GenerateDayArrayDDL(WithSchema("staging"), WithWattHours())
+
+	for _, expected := range []string{
+		"CREATE TABLE IF NOT EXISTS staging.meter_reading_days (",
+		"consumption bigint[] NOT NULL,",
+		"quality_method varchar(3)[] NOT NULL,",
+		"CONSTRAINT meter_reading_days_unique_day UNIQUE (nmi, nmi_suffix, reading_date)",
+		"CREATE OR REPLACE VIEW staging.meter_reading_days_unnested AS",
+		"unnest(d.consumption) WITH ORDINALITY",
+		`AS "timestamp"`,
+	} {
+		if !strings.Contains(ddl, expected) {
+			t.Errorf("DDL doesn't contain %s:\n%s", expected, ddl)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// keywords lists identifiers used in this schema that are also SQL keywords.
+var keywords = map[string]bool{"timestamp": true}
+
+// GenerateDDL returns the statements that create the meter readings table, the
+// unique constraint its INSERT ... ON CONFLICT relies on, and its indexes.
+func GenerateDDL(opts ...Option) string {
+	return generateDDL(newOptions(opts), nil)
+}
+
+// GeneratePartitionedDDL is like GenerateDDL, but the table is range partitioned
+// by month on timestamp, with a partition for every month the readings cover.
+func GeneratePartitionedDDL(readings []csv.Reading, opts ...Option) (string, error) {
+	if len(readings) == 0 {
+		return "", fmt.Errorf("no readings to derive partitions from")
+	}
+	return generateDDL(newOptions(opts), readings), nil
+}
+
+func generateDDL(o options, readings []csv.Reading) string {
+	t := o.meterReadingsTable()
+	name := t.TableName()
+	qualified := qualifiedName(t.SchemaName(), name)
+	partitioned := readings != nil
+
+	consumptionType := "numeric"
+	if o.wattHours {
+		consumptionType = "bigint"
+	}
+	// A partitioned table's primary key has to include the partition key
+	primaryKey := "id"
+	if partitioned {
+		primaryKey = `id, "timestamp"`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", qualified)
+	b.WriteString("    id uuid NOT NULL DEFAULT gen_random_uuid(),\n")
+	b.WriteString("    nmi varchar(10) NOT NULL,\n")
+	b.WriteString("    nmi_suffix varchar(2) NOT NULL,\n")
+	b.WriteString("    \"timestamp\" timestamp NOT NULL,\n")
+	fmt.Fprintf(&b, "    consumption %s NOT NULL,\n", consumptionType)
+	fmt.Fprintf(&b, "    CONSTRAINT %s PRIMARY KEY (%s),\n", quoteIdentifier(name+"_pk"), primaryKey)
+	fmt.Fprintf(&b, "    CONSTRAINT %s UNIQUE (nmi, nmi_suffix, \"timestamp\")\n", quoteIdentifier(name+"_unique_consumption"))
+	b.WriteString(")")
+	if partitioned {
+		b.WriteString(" PARTITION BY RANGE (\"timestamp\")")
+	}
+	b.WriteString(";\n\n")
+
+	fmt.Fprintf(&b, "CREATE INDEX IF NOT EXISTS %s ON %s (\"timestamp\");\n", quoteIdentifier(name+"_timestamp_idx"), qualified)
+
+	if partitioned {
+		b.WriteString("\n")
+		from, to := readingsSpan(readings)
+		for month := startOfMonth(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+			partition := qualifiedName(t.SchemaName(), fmt.Sprintf("%s_%s", name, month.Format("2006_01")))
+			fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s');\n",
+				partition, qualified, month.Format("2006-01-02 15:04:05"), month.AddDate(0, 1, 0).Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	return b.String()
+}
+
+// GenerateSuffixMigration returns the statements that upgrade a meter readings
+// table keyed on (nmi, timestamp) to the (nmi, nmi_suffix, timestamp) key the
+// inserts' ON CONFLICT now relies on, so each channel of an NMI is kept. Rows
+// already in the table get an empty suffix. The old unique constraint, under
+// whatever name it has, is dropped before the new one is added, as it would
+// still reject a second channel's reading for the same interval.
+func GenerateSuffixMigration(opts ...Option) string {
+	t := newOptions(opts).meterReadingsTable()
+	name := t.TableName()
+	qualified := qualifiedName(t.SchemaName(), name)
+	literal := "'" + strings.ReplaceAll(qualified, "'", "''") + "'"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN IF NOT EXISTS nmi_suffix varchar(2) NOT NULL DEFAULT '';\n", qualified)
+	fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN nmi_suffix DROP DEFAULT;\n", qualified)
+	b.WriteString("DO $$\n")
+	b.WriteString("DECLARE\n")
+	b.WriteString("    old_constraint name;\n")
+	b.WriteString("BEGIN\n")
+	b.WriteString("    FOR old_constraint IN\n")
+	b.WriteString("        SELECT c.conname FROM pg_constraint c\n")
+	fmt.Fprintf(&b, "        WHERE c.conrelid = %s::regclass AND c.contype = 'u'\n", literal)
+	b.WriteString("        AND ARRAY(SELECT a.attname::text FROM pg_attribute a\n")
+	b.WriteString("                  WHERE a.attrelid = c.conrelid AND a.attnum = ANY (c.conkey) ORDER BY a.attname) = ARRAY['nmi', 'timestamp']\n")
+	b.WriteString("    LOOP\n")
+	fmt.Fprintf(&b, "        EXECUTE format('ALTER TABLE %%s DROP CONSTRAINT %%I', %s::regclass, old_constraint);\n", literal)
+	b.WriteString("    END LOOP;\n")
+	b.WriteString("END $$;\n")
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (nmi, nmi_suffix, \"timestamp\");\n",
+		qualified, quoteIdentifier(name+"_unique_consumption"))
+	return b.String()
+}
+
+// readingsSpan returns the earliest and latest timestamps in readings.
+func readingsSpan(readings []csv.Reading) (time.Time, time.Time) {
+	from, to := readings[0].Timestamp, readings[0].Timestamp
+	for _, reading := range readings[1:] {
+		if reading.Timestamp.Before(from) {
+			from = reading.Timestamp
+		}
+		if reading.Timestamp.After(to) {
+			to = reading.Timestamp
+		}
+	}
+	return from, to
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return quoteIdentifier(name)
+	}
+	return quoteIdentifier(schema) + "." + quoteIdentifier(name)
+}
+
+// quoteIdentifier quotes name unless it is a plain lower case identifier.
+func quoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) && !keywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
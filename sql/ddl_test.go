@@ -0,0 +1,101 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGenerateDDL(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name: "Default table",
+			expected: []string{
+				"CREATE TABLE IF NOT EXISTS public.meter_readings (",
+				"consumption numeric NOT NULL",
+				`CONSTRAINT meter_readings_unique_consumption UNIQUE (nmi, nmi_suffix, "timestamp")`,
+				`CREATE INDEX IF NOT EXISTS meter_readings_timestamp_idx ON public.meter_readings ("timestamp");`,
+			},
+		},
+		{
+			name: "Configured table and Wh storage",
+			opts: []Option{WithSchema("Staging"), WithTablePrefix("tenant1_"), WithWattHours()},
+			expected: []string{
+				`CREATE TABLE IF NOT EXISTS "Staging".tenant1_meter_readings (`,
+				"consumption bigint NOT NULL",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddl := GenerateDDL(tt.opts...)
+			for _, expected := range tt.expected {
+				if !strings.Contains(ddl, expected) {
+					t.Errorf("DDL doesn't contain %q:\n%s", expected, ddl)
+				}
+			}
+			if strings.Contains(ddl, "PARTITION") {
+				t.Errorf("Expected an unpartitioned table:\n%s", ddl)
+			}
+		})
+	}
+}
+
+func TestGeneratePartitionedDDL(t *testing.T) {
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 31, 0, 30, 0, 0, time.UTC), Consumption: decimal.Zero}},
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 3, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.Zero}},
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Consumption: decimal.Zero}},
+	}
+
+	ddl, err := GeneratePartitionedDDL(readings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(ddl, `PRIMARY KEY (id, "timestamp")`) || !strings.Contains(ddl, `PARTITION BY RANGE ("timestamp")`) {
+		t.Errorf("Expected a table partitioned by timestamp:\n%s", ddl)
+	}
+	for _, month := range []string{"2023_03", "2023_04", "2023_05", "2023_06"} {
+		if !strings.Contains(ddl, "public.meter_readings_"+month+" PARTITION OF public.meter_readings") {
+			t.Errorf("DDL doesn't contain partition for %s:\n%s", month, ddl)
+		}
+	}
+	if strings.Contains(ddl, "2023_07") {
+		t.Errorf("DDL contains a partition past the last reading:\n%s", ddl)
+	}
+
+	if _, err := GeneratePartitionedDDL(nil); err == nil {
+		t.Errorf("Expected an error for no readings, but got none")
+	}
+}
+
+func TestGenerateSuffixMigration(t *testing.T) {
+	migration := GenerateSuffixMigration(WithSchema("billing"))
+
+	for _, expected := range []string{
+		"ALTER TABLE billing.meter_readings ADD COLUMN IF NOT EXISTS nmi_suffix varchar(2) NOT NULL DEFAULT '';",
+		"ALTER TABLE billing.meter_readings ALTER COLUMN nmi_suffix DROP DEFAULT;",
+		"WHERE c.conrelid = 'billing.meter_readings'::regclass AND c.contype = 'u'",
+		"= ARRAY['nmi', 'timestamp']",
+		"EXECUTE format('ALTER TABLE %s DROP CONSTRAINT %I', 'billing.meter_readings'::regclass, old_constraint);",
+		`ALTER TABLE billing.meter_readings ADD CONSTRAINT meter_readings_unique_consumption UNIQUE (nmi, nmi_suffix, "timestamp");`,
+	} {
+		if !strings.Contains(migration, expected) {
+			t.Errorf("Migration doesn't contain %q:\n%s", expected, migration)
+		}
+	}
+	// An old constraint named like the new one has to go first
+	if strings.Index(migration, "DROP CONSTRAINT") > strings.Index(migration, "ADD CONSTRAINT") {
+		t.Errorf("Expected the old constraint to be dropped before the new one is added:\n%s", migration)
+	}
+}
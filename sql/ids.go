@@ -0,0 +1,19 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+
+	"github.com/google/uuid"
+)
+
+// readingNamespace is the UUIDv5 namespace for meter reading IDs. It must never
+// change, or IDs will no longer match those already loaded or exported.
+var readingNamespace = uuid.MustParse("6f1c3a52-8d0e-4b7a-9e25-3c4d7f8a1b06")
+
+// ReadingID returns the deterministic UUIDv5 for a reading, derived from its NMI,
+// NMI suffix (channel) and interval timestamp. The timestamp is taken as the
+// wall-clock time the file recorded, so the ID does not depend on time zones.
+func ReadingID(reading csv.Reading) uuid.UUID {
+	name := reading.Nmi + "|" + reading.NmiSuffix + "|" + reading.Timestamp.Format("2006-01-02T15:04:05")
+	return uuid.NewSHA1(readingNamespace, []byte(name))
+}
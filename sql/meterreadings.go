@@ -1,43 +1,156 @@
 package sql
 
 import (
+	"flo_energy_take_home/csv"
 	"flo_energy_take_home/db/test_flo/public/model"
-	"flo_energy_take_home/db/test_flo/public/table"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 const defaultBatchSize = 10000
 
-func GenerateInsertStatements(readings []model.MeterReadings, batchSize int) ([]string, error) {
+// maxParameters is the most bind parameters Postgres accepts in one statement.
+const maxParameters = 65535
+
+// Batch is one generated statement and a summary of the readings it inserts.
+// Args is only set for parameterized statements, which use $n placeholders.
+// Partition is only set when generating with WithPartitionBy.
+type Batch struct {
+	Statement string
+	Args      []interface{}
+	Rows      int
+	NMIs      []string
+	From      time.Time
+	To        time.Time
+	Partition string
+}
+
+func GenerateInsertStatements(readings []csv.Reading, batchSize int, opts ...Option) ([]string, error) {
+	batches, err := GenerateInsertBatches(readings, batchSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make([]string, len(batches))
+	for i, batch := range batches {
+		statements[i] = batch.Statement
+	}
+	return statements, nil
+}
+
+// GenerateInsertBatches is like GenerateInsertStatements, but also summarises the
+// readings behind each statement.
+func GenerateInsertBatches(readings []csv.Reading, batchSize int, opts ...Option) ([]Batch, error) {
+	o := newOptions(opts)
+	return generatePartitioned(readings, o, func(readings []csv.Reading) ([]Batch, error) {
+		return generateInsertBatches(readings, batchSize, o)
+	})
+}
+
+func generateInsertBatches(readings []csv.Reading, batchSize int, o options) ([]Batch, error) {
+	generate := func(batch []csv.Reading) (Batch, error) {
+		sql, err := generateBatchStatement(batch, o)
+		if err != nil {
+			return Batch{}, err
+		}
+		return newBatch(sql, batch), nil
+	}
+
+	// Staged readings are replaced in the merge, so the batches only insert
+	if o.replace && !o.stagingMerge {
+		if o.maxStatementSize > 0 {
+			return nil, fmt.Errorf("replace mode does not support a maximum statement size")
+		}
+		if batchSize <= 0 {
+			batchSize = defaultBatchSize
+		}
+		return generateBatches(packGroups(groupByChannel(readings), batchSize), func(batch []csv.Reading) (Batch, error) {
+			sql, err := generateReplaceStatement(batch, batchSize, o)
+			if err != nil {
+				return Batch{}, err
+			}
+			return newBatch(sql, batch), nil
+		})
+	}
+
+	if o.maxStatementSize > 0 {
+		chunks, err := splitBySize(readings, batchSize, o)
+		if err != nil {
+			return nil, err
+		}
+		return generateSizedBatches(chunks, o.maxStatementSize, generate)
+	}
+
 	if batchSize <= 0 {
 		batchSize = defaultBatchSize
 	}
+	return generateBatches(splitByCount(readings, batchSize), generate)
+}
 
-	numBatches := (len(readings) + batchSize - 1) / batchSize
-	results := make([]string, numBatches)
-	var wg sync.WaitGroup
-	errChan := make(chan error, numBatches)
+// GenerateParameterizedBatches returns INSERT statements with $n placeholders and
+// their arguments, for running as prepared statements. Batches are capped so no
+// statement exceeds the Postgres limit of 65535 parameters.
+func GenerateParameterizedBatches(readings []csv.Reading, batchSize int, opts ...Option) ([]Batch, error) {
+	o := newOptions(opts)
+	if o.stagingMerge || o.replace {
+		return nil, fmt.Errorf("staging merge and replace are not supported for parameterized statements")
+	}
+	maxBatchSize := maxParameters / len(insertColumns(o))
+	if batchSize <= 0 || batchSize > maxBatchSize {
+		batchSize = maxBatchSize
+	}
+
+	return generateBatches(splitByCount(readings, batchSize), func(batch []csv.Reading) (Batch, error) {
+		sql, args, err := insertStatement(batch, o)
+		if err != nil {
+			return Batch{}, err
+		}
+		result := newBatch(sql, batch)
+		result.Args = args
+		return result, nil
+	})
+}
 
-	for i := 0; i < numBatches; i++ {
-		start := i * batchSize
+// splitByCount splits readings into chunks of at most batchSize readings.
+func splitByCount(readings []csv.Reading, batchSize int) [][]csv.Reading {
+	var chunks [][]csv.Reading
+	for start := 0; start < len(readings); start += batchSize {
 		end := start + batchSize
 		if end > len(readings) {
 			end = len(readings)
 		}
+		chunks = append(chunks, readings[start:end])
+	}
+	return chunks
+}
+
+// generateBatches generates a batch for each chunk concurrently, keeping the
+// batches in the same order as the chunks.
+func generateBatches(chunks [][]csv.Reading, generate func([]csv.Reading) (Batch, error)) ([]Batch, error) {
+	numBatches := len(chunks)
+	results := make([]Batch, numBatches)
+	var wg sync.WaitGroup
+	errChan := make(chan error, numBatches)
 
+	for i, chunk := range chunks {
 		wg.Add(1)
-		go func(i int, batch []model.MeterReadings) {
+		go func(i int, batch []csv.Reading) {
 			defer wg.Done()
-			sql, err := generateBatchInsertStatement(batch)
+			result, err := generate(batch)
 			if err != nil {
 				errChan <- err
 				return
 			}
-			results[i] = sql
-		}(i, readings[start:end])
+			results[i] = result
+		}(i, chunk)
 	}
 
 	wg.Wait()
@@ -50,20 +163,46 @@ func GenerateInsertStatements(readings []model.MeterReadings, batchSize int) ([]
 	return results, nil
 }
 
-func generateBatchInsertStatement(batch []model.MeterReadings) (string, error) {
-	stmt := table.MeterReadings.INSERT(
-		table.MeterReadings.Nmi,
-		table.MeterReadings.Timestamp,
-		table.MeterReadings.Consumption,
-	).MODELS(batch)
+// newBatch summarises the readings behind a generated statement.
+func newBatch(statement string, readings []csv.Reading) Batch {
+	batch := Batch{Statement: statement, Rows: len(readings)}
+	if len(readings) == 0 {
+		return batch
+	}
 
-	onConflict := stmt.ON_CONFLICT(
-		table.MeterReadings.Nmi,
-		table.MeterReadings.Timestamp,
-	).DO_NOTHING()
+	seen := make(map[string]bool)
+	for _, reading := range readings {
+		if !seen[reading.Nmi] {
+			seen[reading.Nmi] = true
+			batch.NMIs = append(batch.NMIs, reading.Nmi)
+		}
+	}
+	sort.Strings(batch.NMIs)
+	batch.From, batch.To = readingsSpan(readings)
 
-	sql, args := onConflict.Sql()
+	return batch
+}
 
+// generateBatchStatement generates the SQL that loads a batch, in whichever
+// mode the options select.
+func generateBatchStatement(batch []csv.Reading, o options) (string, error) {
+	if o.stagingMerge {
+		return generateStagingInsertStatement(batch, o)
+	}
+	return generateBatchInsertStatement(batch, o)
+}
+
+func generateBatchInsertStatement(batch []csv.Reading, o options) (string, error) {
+	sql, args, err := insertStatement(batch, o)
+	if err != nil {
+		return "", err
+	}
+
+	return inlineArgs(sql, args)
+}
+
+// inlineArgs replaces the $n placeholders in sql with the literal values of args.
+func inlineArgs(sql string, args []interface{}) (string, error) {
 	// Replace placeholders with actual values
 	for i, arg := range args {
 		placeholder := fmt.Sprintf("$%d", i+1)
@@ -77,15 +216,148 @@ func generateBatchInsertStatement(batch []model.MeterReadings) (string, error) {
 	return sql, nil
 }
 
+// insertStatement builds the INSERT for a batch, returning its SQL with $n
+// placeholders and the arguments for them.
+func insertStatement(batch []csv.Reading, o options) (string, []interface{}, error) {
+	meterReadings := o.meterReadingsTable()
+	if o.deterministicIDs {
+		batch = withReadingIDs(batch)
+	}
+	if o.wattHours {
+		var err error
+		if batch, err = withWattHours(batch); err != nil {
+			return "", nil, err
+		}
+	}
+
+	stmt := meterReadings.INSERT(insertColumns(o)).MODELS(tableRows(batch))
+
+	// The staging table has no constraints; conflicts are handled by the merge
+	var sql string
+	var args []interface{}
+	if o.staging {
+		sql, args = stmt.Sql()
+	} else {
+		sql, args = stmt.ON_CONFLICT(
+			meterReadings.Nmi,
+			meterReadings.NmiSuffix,
+			meterReadings.Timestamp,
+		).DO_NOTHING().Sql()
+	}
+
+	if o.wattHours {
+		argsToIntegers(args)
+	}
+
+	return sql, args, nil
+}
+
+// argsToIntegers converts the decimal arguments of a statement, which
+// withWattHours has made whole numbers of Wh, to integers.
+func argsToIntegers(args []interface{}) {
+	for i, arg := range args {
+		if d, ok := arg.(decimal.Decimal); ok {
+			args[i] = d.IntPart()
+		}
+	}
+}
+
+// insertColumns returns the columns each inserted row sets.
+func insertColumns(o options) postgres.ColumnList {
+	meterReadings := o.meterReadingsTable()
+	columns := postgres.ColumnList{
+		meterReadings.Nmi,
+		meterReadings.NmiSuffix,
+		meterReadings.Timestamp,
+		meterReadings.Consumption,
+	}
+	if o.deterministicIDs {
+		columns = append(postgres.ColumnList{meterReadings.ID}, columns...)
+	}
+	return columns
+}
+
 func formatValue(v interface{}) (string, error) {
 	switch val := v.(type) {
 	case string:
 		return fmt.Sprintf("'%s'", strings.ReplaceAll(val, "'", "''")), nil
+	case uuid.UUID:
+		return fmt.Sprintf("'%s'", val.String()), nil
 	case time.Time:
 		return fmt.Sprintf("'%s'", val.Format("2006-01-02 15:04:05")), nil
 	case float64:
 		return fmt.Sprintf("%f", val), nil
+	case decimal.Decimal:
+		return val.String(), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case int32:
+		return strconv.FormatInt(int64(val), 10), nil
 	default:
 		return "", fmt.Errorf("unsupported type for argument")
 	}
 }
+
+// wattHourShifts are the powers of ten that convert consumption in each energy
+// unit of measure to Wh. Files vary in case, so units are matched in lower case.
+var wattHourShifts = map[string]int32{"wh": 0, "kwh": 3, "mwh": 6}
+
+// toWattHours converts a reading's consumption to whole Wh by its unit of
+// measure, failing for a unit that isn't energy or if that would lose precision.
+func toWattHours(reading csv.Reading) (int64, error) {
+	shift, ok := wattHourShifts[strings.ToLower(reading.Uom)]
+	if !ok {
+		return 0, fmt.Errorf("consumption of %s %s is in %q, which can't be converted to Wh", reading.Nmi, reading.NmiSuffix, reading.Uom)
+	}
+	wh := reading.Consumption.Shift(shift)
+	if !wh.IsInteger() {
+		return 0, fmt.Errorf("consumption %s %s is not a whole number of Wh", reading.Consumption, reading.Uom)
+	}
+	return wh.IntPart(), nil
+}
+
+// KilowattHours converts a reading's consumption to kWh by its unit of measure,
+// failing for a unit that isn't energy.
+func KilowattHours(reading csv.Reading) (decimal.Decimal, error) {
+	shift, ok := wattHourShifts[strings.ToLower(reading.Uom)]
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("consumption of %s %s is in %q, which can't be converted to kWh", reading.Nmi, reading.NmiSuffix, reading.Uom)
+	}
+	return reading.Consumption.Shift(shift - wattHourShifts["kwh"]), nil
+}
+
+// withWattHours returns a copy of the batch with each consumption converted to
+// whole Wh by toWattHours.
+func withWattHours(batch []csv.Reading) ([]csv.Reading, error) {
+	converted := make([]csv.Reading, len(batch))
+	for i, reading := range batch {
+		wh, err := toWattHours(reading)
+		if err != nil {
+			return nil, err
+		}
+		reading.Consumption = decimal.NewFromInt(wh)
+		reading.Uom = "Wh"
+		converted[i] = reading
+	}
+	return converted, nil
+}
+
+// tableRows returns the meter_readings rows of a batch, without the parser's
+// details of each interval.
+func tableRows(batch []csv.Reading) []model.MeterReadings {
+	rows := make([]model.MeterReadings, len(batch))
+	for i, reading := range batch {
+		rows[i] = reading.MeterReadings
+	}
+	return rows
+}
+
+// withReadingIDs returns a copy of the batch with each ID set by ReadingID.
+func withReadingIDs(batch []csv.Reading) []csv.Reading {
+	withIDs := make([]csv.Reading, len(batch))
+	for i, reading := range batch {
+		reading.ID = ReadingID(reading)
+		withIDs[i] = reading
+	}
+	return withIDs
+}
@@ -1,17 +1,19 @@
 package sql
 
 import (
+	"flo_energy_take_home/csv"
 	"flo_energy_take_home/db/test_flo/public/model"
-	"fmt"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestGenerateInsertStatements(t *testing.T) {
 	tests := []struct {
 		name           string
-		readings       []model.MeterReadings
+		readings       []csv.Reading
 		batchSize      int
 		expectedLen    int
 		expectError    bool
@@ -19,9 +21,9 @@ func TestGenerateInsertStatements(t *testing.T) {
 	}{
 		{
 			name: "Happy path - single batch",
-			readings: []model.MeterReadings{
-				{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: 10.5},
-				{Nmi: "NMI2", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: 11.5},
+			readings: []csv.Reading{
+				{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("10.5")}},
+				{MeterReadings: model.MeterReadings{Nmi: "NMI2", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("11.5")}},
 			},
 			batchSize:   10,
 			expectedLen: 1,
@@ -29,10 +31,10 @@ func TestGenerateInsertStatements(t *testing.T) {
 		},
 		{
 			name: "Happy path - multiple batches",
-			readings: []model.MeterReadings{
-				{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: 10.5},
-				{Nmi: "NMI2", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: 11.5},
-				{Nmi: "NMI3", Timestamp: time.Date(2023, 5, 1, 2, 0, 0, 0, time.UTC), Consumption: 12.5},
+			readings: []csv.Reading{
+				{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("10.5")}},
+				{MeterReadings: model.MeterReadings{Nmi: "NMI2", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("11.5")}},
+				{MeterReadings: model.MeterReadings{Nmi: "NMI3", Timestamp: time.Date(2023, 5, 1, 2, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("12.5")}},
 			},
 			batchSize:   2,
 			expectedLen: 2,
@@ -40,15 +42,15 @@ func TestGenerateInsertStatements(t *testing.T) {
 		},
 		{
 			name:        "Empty readings",
-			readings:    []model.MeterReadings{},
+			readings:    []csv.Reading{},
 			batchSize:   10,
 			expectedLen: 0,
 			expectError: false,
 		},
 		{
 			name: "Invalid batch size",
-			readings: []model.MeterReadings{
-				{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: 10.5},
+			readings: []csv.Reading{
+				{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("10.5")}},
 			},
 			batchSize:   0, // Should use default batch size
 			expectedLen: 1,
@@ -91,28 +93,28 @@ func TestGenerateInsertStatements(t *testing.T) {
 func TestGenerateBatchInsertStatement(t *testing.T) {
 	tests := []struct {
 		name           string
-		batch          []model.MeterReadings
+		batch          []csv.Reading
 		expectError    bool
 		errorSubstring string
 	}{
 		{
 			name: "Happy path",
-			batch: []model.MeterReadings{
-				{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: 10.5},
-				{Nmi: "NMI2", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: 11.5},
+			batch: []csv.Reading{
+				{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("10.5")}},
+				{MeterReadings: model.MeterReadings{Nmi: "NMI2", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("11.5")}},
 			},
 			expectError: false,
 		},
 		{
 			name:        "Empty batch",
-			batch:       []model.MeterReadings{},
+			batch:       []csv.Reading{},
 			expectError: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sql, err := generateBatchInsertStatement(tt.batch)
+			sql, err := generateBatchInsertStatement(tt.batch, options{})
 
 			if tt.expectError {
 				if err == nil {
@@ -139,8 +141,8 @@ func TestGenerateBatchInsertStatement(t *testing.T) {
 					if !strings.Contains(sql, reading.Timestamp.Format("2006-01-02 15:04:05")) {
 						t.Errorf("SQL doesn't contain expected timestamp: %s", reading.Timestamp.Format("2006-01-02 15:04:05"))
 					}
-					if !strings.Contains(sql, fmt.Sprintf("%f", reading.Consumption)) {
-						t.Errorf("SQL doesn't contain expected consumption: %f", reading.Consumption)
+					if !strings.Contains(sql, reading.Consumption.String()) {
+						t.Errorf("SQL doesn't contain expected consumption: %s", reading.Consumption)
 					}
 				}
 			}
@@ -179,6 +181,12 @@ func TestFormatValue(t *testing.T) {
 			expected:    "10.500000",
 			expectError: false,
 		},
+		{
+			name:        "Decimal",
+			input:       decimal.RequireFromString("0.1234567891"),
+			expected:    "0.1234567891",
+			expectError: false,
+		},
 		{
 			name:        "Unsupported type",
 			input:       []int{1, 2, 3},
@@ -206,3 +214,153 @@ func TestFormatValue(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateInsertStatementsWattHours(t *testing.T) {
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.234")}, Uom: "kWh"},
+	}
+
+	results, err := GenerateInsertStatements(readings, 10, WithWattHours())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(results[0], "1234") || strings.Contains(results[0], "1.234") {
+		t.Errorf("SQL doesn't contain consumption as whole Wh: %s", results[0])
+	}
+
+	readings[0].Consumption = decimal.RequireFromString("1.2345")
+	_, err = GenerateInsertStatements(readings, 10, WithWattHours())
+	if err == nil || !strings.Contains(err.Error(), "not a whole number of Wh") {
+		t.Errorf("Expected whole Wh error, but got: %v", err)
+	}
+}
+
+func TestToWattHours(t *testing.T) {
+	tests := []struct {
+		name         string
+		consumption  string
+		uom          string
+		expected     int64
+		errorMessage string
+	}{
+		{name: "kWh", consumption: "1.234", uom: "kWh", expected: 1234},
+		{name: "Upper case kWh", consumption: "1.234", uom: "KWH", expected: 1234},
+		{name: "Wh", consumption: "1234", uom: "Wh", expected: 1234},
+		{name: "MWh", consumption: "0.001234", uom: "MWh", expected: 1234},
+		{name: "Fraction of a Wh", consumption: "1.5", uom: "Wh", errorMessage: "not a whole number of Wh"},
+		{name: "Not energy", consumption: "1.234", uom: "kVArh", errorMessage: "can't be converted to Wh"},
+		{name: "No unit", consumption: "1.234", uom: "", errorMessage: "can't be converted to Wh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading := csv.Reading{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Consumption: decimal.RequireFromString(tt.consumption)}, Uom: tt.uom}
+			wh, err := toWattHours(reading)
+			if tt.errorMessage != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMessage) {
+					t.Errorf("Expected error containing %q, but got: %v", tt.errorMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if wh != tt.expected {
+				t.Errorf("Expected %d Wh, but got %d", tt.expected, wh)
+			}
+		})
+	}
+}
+
+func TestGenerateInsertStatementsDeterministicIDs(t *testing.T) {
+	reading := csv.Reading{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.5")}}
+
+	first, err := GenerateInsertStatements([]csv.Reading{reading}, 10, WithDeterministicIDs())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := GenerateInsertStatements([]csv.Reading{reading}, 10, WithDeterministicIDs())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first[0] != second[0] {
+		t.Errorf("Expected identical SQL for the same reading, got:\n%s\n%s", first[0], second[0])
+	}
+
+	id := ReadingID(reading)
+	if id.Version() != 5 {
+		t.Errorf("Expected a version 5 UUID, but got version %d", id.Version())
+	}
+	if !strings.Contains(first[0], id.String()) {
+		t.Errorf("SQL doesn't contain expected ID %s: %s", id, first[0])
+	}
+
+	other := reading
+	other.NmiSuffix = "B1"
+	if ReadingID(other) == id {
+		t.Errorf("Expected different IDs for different channels")
+	}
+}
+
+func TestGenerateInsertStatementsTargetTable(t *testing.T) {
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.5")}},
+	}
+
+	results, err := GenerateInsertStatements(readings, 10, WithSchema("staging"), WithTablePrefix("tenant1_"), WithTableSuffix("_2023"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(results[0], "INSERT INTO staging.tenant1_meter_readings_2023") {
+		t.Errorf("SQL doesn't target the configured table: %s", results[0])
+	}
+}
+
+func TestGenerateParameterizedBatches(t *testing.T) {
+	readings := make([]csv.Reading, 20000)
+	for i := range readings {
+		readings[i] = csv.Reading{
+			MeterReadings: model.MeterReadings{
+				Nmi:         "NMI1",
+				NmiSuffix:   "E1",
+				Timestamp:   time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * 5 * time.Minute),
+				Consumption: decimal.RequireFromString("1.5"),
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		batchSize       int
+		opts            []Option
+		expectedBatches int
+	}{
+		{name: "Requested batch size", batchSize: 5000, expectedBatches: 4},
+		{name: "Capped at the parameter limit", batchSize: 0, expectedBatches: 2},
+		{name: "Cap accounts for the ID column", batchSize: 0, opts: []Option{WithDeterministicIDs()}, expectedBatches: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches, err := GenerateParameterizedBatches(readings, tt.batchSize, tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(batches) != tt.expectedBatches {
+				t.Errorf("Expected %d batches, but got %d", tt.expectedBatches, len(batches))
+			}
+
+			for _, batch := range batches {
+				if len(batch.Args) > 65535 {
+					t.Errorf("Batch has %d parameters, more than Postgres allows", len(batch.Args))
+				}
+				if len(batch.Args) != batch.Rows*len(insertColumns(newOptions(tt.opts))) {
+					t.Errorf("Expected %d args per row, but got %d for %d rows", len(insertColumns(newOptions(tt.opts))), len(batch.Args), batch.Rows)
+				}
+				if !strings.Contains(batch.Statement, "$1") || strings.Contains(batch.Statement, "NMI1") {
+					t.Errorf("Expected a parameterized statement: %.200s", batch.Statement)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package sql
+
+import "flo_energy_take_home/db/test_flo/public/table"
+
+// Option configures how statements are generated.
+type Option func(*options)
+
+type options struct {
+	wattHours        bool
+	deterministicIDs bool
+	schema           string
+	tablePrefix      string
+	tableSuffix      string
+	maxStatementSize int64
+	stagingMerge     bool
+	replace          bool
+	partitionBy      string
+	dayArrays        bool
+	// staging retargets the table at the staging table
+	staging bool
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// retargetable is a generated table that can be moved to another schema or name.
+type retargetable[T any] interface {
+	FromSchema(schemaName string) T
+	WithPrefix(prefix string) T
+	WithSuffix(suffix string) T
+}
+
+// retarget returns t in the configured schema, with the configured table prefix
+// and suffix.
+func retarget[T retargetable[T]](t T, o options) T {
+	if o.schema != "" {
+		t = t.FromSchema(o.schema)
+	}
+	if o.tablePrefix != "" {
+		t = t.WithPrefix(o.tablePrefix)
+	}
+	if o.tableSuffix != "" {
+		t = t.WithSuffix(o.tableSuffix)
+	}
+	return t
+}
+
+// meterReadingsTable returns the generated meter_readings table, retargeted at
+// the configured schema and table name.
+func (o options) meterReadingsTable() *table.MeterReadingsTable {
+	t := retarget(table.MeterReadings, o)
+	if o.staging {
+		t = t.WithSuffix(stagingSuffix)
+	}
+	return t
+}
+
+// meterReadingDaysTable returns the generated meter_reading_days table,
+// retargeted at the configured schema and table name.
+func (o options) meterReadingDaysTable() *table.MeterReadingDaysTable {
+	return retarget(table.MeterReadingDays, o)
+}
+
+// meterReadingsHourlyTable returns the generated meter_readings_hourly table,
+// retargeted at the configured schema and table name.
+func (o options) meterReadingsHourlyTable() *table.MeterReadingsHourlyTable {
+	return retarget(table.MeterReadingsHourly, o)
+}
+
+// meterReadingsDailyTable returns the generated meter_readings_daily table,
+// retargeted at the configured schema and table name.
+func (o options) meterReadingsDailyTable() *table.MeterReadingsDailyTable {
+	return retarget(table.MeterReadingsDaily, o)
+}
+
+// meterReadingsMonthlyTable returns the generated meter_readings_monthly table,
+// retargeted at the configured schema and table name.
+func (o options) meterReadingsMonthlyTable() *table.MeterReadingsMonthlyTable {
+	return retarget(table.MeterReadingsMonthly, o)
+}
+
+// WithSchema targets the given schema instead of public.
+func WithSchema(schema string) Option {
+	return func(o *options) {
+		o.schema = schema
+	}
+}
+
+// WithTablePrefix prepends prefix to the table name, e.g. tenant1_meter_readings.
+func WithTablePrefix(prefix string) Option {
+	return func(o *options) {
+		o.tablePrefix = prefix
+	}
+}
+
+// WithTableSuffix appends suffix to the table name, e.g. meter_readings_2024.
+func WithTableSuffix(suffix string) Option {
+	return func(o *options) {
+		o.tableSuffix = suffix
+	}
+}
+
+// WithWattHours writes consumption as a fixed-point integer number of Wh
+// instead of a decimal kWh value. Readings are converted by their unit of
+// measure, so a channel in a unit other than Wh, kWh or MWh is rejected, as is
+// a value that is not a whole number of Wh rather than being rounded.
+func WithWattHours() Option {
+	return func(o *options) {
+		o.wattHours = true
+	}
+}
+
+// WithDeterministicIDs includes the primary key in each INSERT, computed by
+// ReadingID, rather than leaving it to the database.
+func WithDeterministicIDs() Option {
+	return func(o *options) {
+		o.deterministicIDs = true
+	}
+}
+
+// WithMaxStatementSize packs readings into statements of at most size bytes,
+// instead of a fixed number of readings per statement.
+func WithMaxStatementSize(size int64) Option {
+	return func(o *options) {
+		o.maxStatementSize = size
+	}
+}
+
+// WithStagingMerge bulk inserts every batch into a staging table, which the
+// batches from GenerateStagingBatches create beforehand and merge into the
+// target table afterwards with a single INSERT ... SELECT.
+func WithStagingMerge() Option {
+	return func(o *options) {
+		o.stagingMerge = true
+	}
+}
+
+// WithReplace deletes the existing readings for every NMI, suffix and date range
+// a batch covers before inserting, so the table mirrors the latest file even
+// where intervals were removed. Batches never split an NMI and suffix. With
+// WithStagingMerge, the deletes happen in the merge instead, for every day
+// staged.
+func WithReplace() Option {
+	return func(o *options) {
+		o.replace = true
+	}
+}
+
+// WithPartitionBy splits batches by partition, PartitionByNMI or
+// PartitionByMonth, so that no batch holds readings from two partitions. Each
+// batch records its partition, for writing it to a file of its own.
+func WithPartitionBy(partition string) Option {
+	return func(o *options) {
+		o.partitionBy = partition
+	}
+}
+
+// WithDayArrays recomputes rollups from the readings stored in the day array
+// layout, through its unnested view, instead of from meter_readings.
+func WithDayArrays() Option {
+	return func(o *options) {
+		o.dayArrays = true
+	}
+}
@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"sort"
+)
+
+// Partition keys for WithPartitionBy.
+const (
+	PartitionByNMI   = "nmi"
+	PartitionByMonth = "month"
+)
+
+// partitionKey returns the partition a reading belongs to: its NMI, or the
+// month of its interval as YYYY-MM.
+func (o options) partitionKey(reading csv.Reading) string {
+	if o.partitionBy == PartitionByNMI {
+		return reading.Nmi
+	}
+	return csv.ReadingDay(reading.Timestamp).Format("2006-01")
+}
+
+// generatePartitioned splits readings into partitions, in key order, and
+// generates the batches of each separately, so no batch crosses a partition
+// boundary. Without partitioning, the readings are generated as a whole.
+func generatePartitioned(readings []csv.Reading, o options, generate func([]csv.Reading) ([]Batch, error)) ([]Batch, error) {
+	switch o.partitionBy {
+	case "":
+		return generate(readings)
+	case PartitionByNMI, PartitionByMonth:
+	default:
+		return nil, fmt.Errorf("unknown partition %q, use %s or %s", o.partitionBy, PartitionByNMI, PartitionByMonth)
+	}
+
+	partitions := groupBy(readings, o.partitionKey)
+	sort.Slice(partitions, func(i, j int) bool {
+		return o.partitionKey(partitions[i][0]) < o.partitionKey(partitions[j][0])
+	})
+
+	var batches []Batch
+	for _, partition := range partitions {
+		partitionBatches, err := generate(partition)
+		if err != nil {
+			return nil, err
+		}
+		key := o.partitionKey(partition[0])
+		for i := range partitionBatches {
+			partitionBatches[i].Partition = key
+		}
+		batches = append(batches, partitionBatches...)
+	}
+	return batches, nil
+}
@@ -0,0 +1,85 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateInsertBatchesPartitioned(t *testing.T) {
+	feb28 := time.Date(2005, 2, 28, 0, 0, 0, 0, time.UTC)
+	var readings []csv.Reading
+	readings = append(readings, dayOfReadings("NMI2", "E1", feb28)...)
+	readings = append(readings, dayOfReadings("NMI2", "E1", feb28.AddDate(0, 0, 1))...)
+	readings = append(readings, dayOfReadings("NMI1", "E1", feb28)...)
+	readings = append(readings, dayOfReadings("NMI1", "E1", feb28.AddDate(0, 0, 1))...)
+
+	tests := []struct {
+		name               string
+		partition          string
+		batchSize          int
+		expectedPartitions []string
+		expectError        string
+	}{
+		{
+			name:               "By NMI",
+			partition:          PartitionByNMI,
+			batchSize:          100,
+			expectedPartitions: []string{"NMI1", "NMI2"},
+		},
+		{
+			// The last interval of 28 February ends at midnight on 1 March
+			name:               "By month",
+			partition:          PartitionByMonth,
+			batchSize:          100,
+			expectedPartitions: []string{"2005-02", "2005-03"},
+		},
+		{
+			name:               "Batches split within a partition",
+			partition:          PartitionByNMI,
+			batchSize:          60,
+			expectedPartitions: []string{"NMI1", "NMI1", "NMI2", "NMI2"},
+		},
+		{
+			name:        "Unknown partition",
+			partition:   "week",
+			expectError: "unknown partition",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches, err := GenerateInsertBatches(readings, tt.batchSize, WithPartitionBy(tt.partition))
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected an error containing %q, but got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(batches) != len(tt.expectedPartitions) {
+				t.Fatalf("Expected %d batches, but got %d", len(tt.expectedPartitions), len(batches))
+			}
+			rows := 0
+			for i, batch := range batches {
+				if batch.Partition != tt.expectedPartitions[i] {
+					t.Errorf("Expected batch %d in partition %s, but got %s", i, tt.expectedPartitions[i], batch.Partition)
+				}
+				if tt.partition == PartitionByNMI && (len(batch.NMIs) != 1 || batch.NMIs[0] != batch.Partition) {
+					t.Errorf("Batch %d in partition %s holds NMIs %v", i, batch.Partition, batch.NMIs)
+				}
+				if tt.partition == PartitionByMonth && csv.ReadingDay(batch.To).Format("2006-01") != batch.Partition {
+					t.Errorf("Batch %d in partition %s ends at %v", i, batch.Partition, batch.To)
+				}
+				rows += batch.Rows
+			}
+			if rows != len(readings) {
+				t.Errorf("Expected %d rows across the batches, but got %d", len(readings), rows)
+			}
+		})
+	}
+}
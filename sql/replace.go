@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+// generateReplaceStatement deletes the existing readings for each NMI and suffix
+// in the batch on the days the batch covers, then inserts the batch in
+// statements of at most batchSize readings.
+func generateReplaceStatement(batch []csv.Reading, batchSize int, o options) (string, error) {
+	var b strings.Builder
+	for _, group := range groupByChannel(batch) {
+		statement, err := generateDeleteStatement(group, o)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(statement)
+	}
+
+	for _, chunk := range splitByCount(batch, batchSize) {
+		statement, err := generateBatchStatement(chunk, o)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strings.TrimRight(strings.TrimSpace(statement), ";") + ";\n")
+	}
+
+	return b.String(), nil
+}
+
+// generateDeleteStatement deletes the readings for one NMI and suffix on the
+// days the group has readings for, with a statement for each run of
+// consecutive days, so days missing from the group are left alone.
+func generateDeleteStatement(group []csv.Reading, o options) (string, error) {
+	meterReadings := o.meterReadingsTable()
+
+	var b strings.Builder
+	for _, run := range dayRuns(group) {
+		// Timestamps mark the end of an interval, so a day runs from just after
+		// midnight up to and including the following midnight
+		stmt := meterReadings.DELETE().WHERE(
+			meterReadings.Nmi.EQ(postgres.String(group[0].Nmi)).
+				AND(meterReadings.NmiSuffix.EQ(postgres.String(group[0].NmiSuffix))).
+				AND(meterReadings.Timestamp.GT(postgres.TimestampT(run[0]))).
+				AND(meterReadings.Timestamp.LT_EQ(postgres.TimestampT(run[1].AddDate(0, 0, 1)))),
+		)
+
+		sql, args := stmt.Sql()
+		statement, err := inlineArgs(sql, args)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strings.TrimRight(strings.TrimSpace(statement), ";") + ";\n")
+	}
+	return b.String(), nil
+}
+
+// dayRuns returns the first and last day of each run of consecutive days the
+// readings belong to, in order.
+func dayRuns(readings []csv.Reading) [][2]time.Time {
+	seen := make(map[time.Time]bool)
+	var days []time.Time
+	for _, reading := range readings {
+		day := csv.ReadingDay(reading.Timestamp)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var runs [][2]time.Time
+	for _, day := range days {
+		if n := len(runs); n > 0 && runs[n-1][1].AddDate(0, 0, 1).Equal(day) {
+			runs[n-1][1] = day
+			continue
+		}
+		runs = append(runs, [2]time.Time{day, day})
+	}
+	return runs
+}
+
+// groupByChannel groups readings by NMI and suffix, in order of first appearance.
+func groupByChannel(readings []csv.Reading) [][]csv.Reading {
+	return groupBy(readings, func(reading csv.Reading) string {
+		return reading.Nmi + "|" + reading.NmiSuffix
+	})
+}
+
+// groupBy groups readings by key, in order of first appearance.
+func groupBy(readings []csv.Reading, key func(csv.Reading) string) [][]csv.Reading {
+	index := make(map[string]int)
+	var groups [][]csv.Reading
+	for _, reading := range readings {
+		k := key(reading)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], reading)
+	}
+	return groups
+}
+
+// packGroups combines whole groups into batches of up to batchSize readings.
+// A group is never split, so one larger than batchSize gets a batch to itself.
+func packGroups(groups [][]csv.Reading, batchSize int) [][]csv.Reading {
+	var batches [][]csv.Reading
+	var current []csv.Reading
+	for _, group := range groups {
+		if len(current) > 0 && len(current)+len(group) > batchSize {
+			batches = append(batches, current)
+			current = nil
+		}
+		current = append(current, group...)
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func dayOfReadings(nmi, suffix string, day time.Time) []csv.Reading {
+	readings := make([]csv.Reading, 48)
+	for i := range readings {
+		readings[i] = csv.Reading{
+			MeterReadings: model.MeterReadings{
+				Nmi:         nmi,
+				NmiSuffix:   suffix,
+				Timestamp:   day.Add(time.Duration(i+1) * 30 * time.Minute),
+				Consumption: decimal.RequireFromString("0.5"),
+			},
+			Uom: "kWh",
+		}
+	}
+	return readings
+}
+
+func TestGenerateInsertBatchesReplace(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	var readings []csv.Reading
+	readings = append(readings, dayOfReadings("NMI1", "E1", march1)...)
+	readings = append(readings, dayOfReadings("NMI2", "E1", march1)...)
+	readings = append(readings, dayOfReadings("NMI1", "E1", march1.AddDate(0, 0, 2))...)
+	readings = append(readings, dayOfReadings("NMI1", "B1", march1)...)
+
+	batches, err := GenerateInsertBatches(readings, 100, WithReplace())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// NMI1/E1 has 96 readings, so it gets a batch of its own
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 batches, but got %d", len(batches))
+	}
+	if batches[0].Rows != 96 || len(batches[0].NMIs) != 1 || batches[0].NMIs[0] != "NMI1" {
+		t.Errorf("Expected the first batch to hold all of NMI1/E1, but got %d rows of %v", batches[0].Rows, batches[0].NMIs)
+	}
+
+	first := batches[0].Statement
+	deleteIndex := strings.Index(first, "DELETE FROM public.meter_readings")
+	insertIndex := strings.Index(first, "INSERT INTO public.meter_readings")
+	if deleteIndex < 0 || insertIndex < deleteIndex {
+		t.Fatalf("Expected the DELETE before the INSERT:\n%.500s", first)
+	}
+	// NMI1/E1 has 1 and 3 March but not 2 March, which must be left alone
+	deletes := first[:insertIndex]
+	if count := strings.Count(deletes, "DELETE FROM"); count != 2 {
+		t.Errorf("Expected a DELETE for each of 1 and 3 March, but got %d:\n%s", count, deletes)
+	}
+	for _, expected := range []string{"'NMI1'", "'E1'", "> '2005-03-01 00:00:00'", "<= '2005-03-02 00:00:00'", "> '2005-03-03 00:00:00'", "<= '2005-03-04 00:00:00'"} {
+		if !strings.Contains(deletes, expected) {
+			t.Errorf("DELETE doesn't contain %s:\n%s", expected, deletes)
+		}
+	}
+	if strings.Contains(deletes, "<= '2005-03-03 00:00:00'") {
+		t.Errorf("DELETE covers 2 March, which isn't in the file:\n%s", deletes)
+	}
+
+	if strings.Count(batches[1].Statement, "DELETE FROM") != 2 {
+		t.Errorf("Expected a DELETE for each of NMI2/E1 and NMI1/B1:\n%.500s", batches[1].Statement)
+	}
+}
+
+func TestDayRuns(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return march1.AddDate(0, 0, n-1) }
+
+	tests := []struct {
+		name     string
+		days     []int
+		expected [][2]time.Time
+	}{
+		{name: "One day", days: []int{1}, expected: [][2]time.Time{{day(1), day(1)}}},
+		{name: "Consecutive days", days: []int{2, 1, 3}, expected: [][2]time.Time{{day(1), day(3)}}},
+		{name: "Days with a gap", days: []int{1, 2, 4}, expected: [][2]time.Time{{day(1), day(2)}, {day(4), day(4)}}},
+		{name: "Month boundary", days: []int{28, 29, 31, 32}, expected: [][2]time.Time{{day(28), day(29)}, {day(31), day(32)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readings []csv.Reading
+			for _, n := range tt.days {
+				readings = append(readings, dayOfReadings("NMI1", "E1", day(n))...)
+			}
+
+			runs := dayRuns(readings)
+			if len(runs) != len(tt.expected) {
+				t.Fatalf("Expected %d runs, but got %v", len(tt.expected), runs)
+			}
+			for i, run := range runs {
+				if !run[0].Equal(tt.expected[i][0]) || !run[1].Equal(tt.expected[i][1]) {
+					t.Errorf("Expected run %d to be %v, but got %v", i, tt.expected[i], run)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateInsertBatchesReplaceLargeGroup(t *testing.T) {
+	readings := dayOfReadings("NMI1", "E1", time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	batches, err := GenerateInsertBatches(readings, 20, WithReplace())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The group can't be split across files, so it's one file of several inserts
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, but got %d", len(batches))
+	}
+	if count := strings.Count(batches[0].Statement, "INSERT INTO"); count != 3 {
+		t.Errorf("Expected 3 INSERT statements, but got %d", count)
+	}
+	if count := strings.Count(batches[0].Statement, "DELETE FROM"); count != 1 {
+		t.Errorf("Expected 1 DELETE statement, but got %d", count)
+	}
+}
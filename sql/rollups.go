@@ -0,0 +1,142 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/rollup"
+	"fmt"
+	"strings"
+
+	"github.com/go-jet/jet/v2/postgres"
+)
+
+// GenerateRollupBatches generates statements that recompute the hourly, daily
+// and monthly totals of every period the readings cover from the readings
+// stored in the target table, and upsert them into their rollup tables. A total
+// therefore includes the readings of its period loaded from other files, as
+// long as the statements run after the readings are loaded. An NMI and suffix
+// is only split across batches to keep them within the maximum statement size,
+// where a period on both sides of the split is recomputed by each. Only the
+// table, partition, size and day array options apply.
+func GenerateRollupBatches(readings []csv.Reading, batchSize int, opts ...Option) ([]Batch, error) {
+	o := newOptions(opts)
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return generatePartitioned(readings, o, func(readings []csv.Reading) ([]Batch, error) {
+		chunks := packGroups(groupByChannel(readings), batchSize)
+		generate := func(batch []csv.Reading) (Batch, error) {
+			sql, rows, err := generateRollupStatement(batch, o)
+			if err != nil {
+				return Batch{}, err
+			}
+			result := newBatch(sql, batch)
+			result.Rows = rows
+			return result, nil
+		}
+		if o.maxStatementSize > 0 {
+			return generateSizedBatches(chunks, o.maxStatementSize, generate)
+		}
+		return generateBatches(chunks, generate)
+	})
+}
+
+// generateRollupStatement returns the upserts for each rollup of the batch, and
+// the number of rollup rows they write.
+func generateRollupStatement(batch []csv.Reading, o options) (string, int, error) {
+	hourly := rollup.Hourly(batch)
+	daily := rollup.Daily(batch)
+	monthly := rollup.Monthly(batch)
+
+	// The day array layout is read through its view, which has one row per interval
+	source := o.meterReadingsTable()
+	sourceName := qualifiedName(source.SchemaName(), source.TableName())
+	if o.dayArrays {
+		days := o.meterReadingDaysTable()
+		sourceName = qualifiedName(days.SchemaName(), days.TableName()+"_unnested")
+	}
+
+	statements := []struct {
+		table   postgres.Table
+		length  string
+		periods []rollup.Period
+	}{
+		{table: o.meterReadingsHourlyTable(), length: "1 hour", periods: hourly},
+		{table: o.meterReadingsDailyTable(), length: "1 day", periods: daily},
+		{table: o.meterReadingsMonthlyTable(), length: "1 month", periods: monthly},
+	}
+
+	var b strings.Builder
+	for _, s := range statements {
+		values := make([]string, len(s.periods))
+		for i, p := range s.periods {
+			row, err := rollupValues(p)
+			if err != nil {
+				return "", 0, err
+			}
+			values[i] = row
+		}
+
+		// Timestamps mark the end of an interval, so a period runs from just after
+		// its start up to and including its end
+		fmt.Fprintf(&b, "INSERT INTO %s (nmi, nmi_suffix, period_start, consumption, interval_count, expected_intervals, completeness)\n", qualifiedName(s.table.SchemaName(), s.table.TableName()))
+		b.WriteString("SELECT p.nmi, p.nmi_suffix, p.period_start, sum(r.consumption), count(*), p.expected_intervals,\n")
+		b.WriteString("    coalesce(round(count(*)::numeric / nullif(p.expected_intervals, 0), 4), 0)\n")
+		fmt.Fprintf(&b, "FROM (VALUES %s) AS p (nmi, nmi_suffix, period_start, expected_intervals)\n", strings.Join(values, ", "))
+		fmt.Fprintf(&b, "JOIN %s r ON r.nmi = p.nmi AND r.nmi_suffix = p.nmi_suffix\n", sourceName)
+		fmt.Fprintf(&b, "    AND r.\"timestamp\" > p.period_start AND r.\"timestamp\" <= p.period_start + interval '%s'\n", s.length)
+		b.WriteString("GROUP BY p.nmi, p.nmi_suffix, p.period_start, p.expected_intervals\n")
+		b.WriteString("ON CONFLICT (nmi, nmi_suffix, period_start) DO UPDATE SET consumption = EXCLUDED.consumption, ")
+		b.WriteString("interval_count = EXCLUDED.interval_count, expected_intervals = EXCLUDED.expected_intervals, completeness = EXCLUDED.completeness;\n")
+	}
+
+	return b.String(), len(hourly) + len(daily) + len(monthly), nil
+}
+
+// rollupValues returns the VALUES row for a period to recompute.
+func rollupValues(p rollup.Period) (string, error) {
+	fields := []interface{}{p.Nmi, p.NmiSuffix, p.Start, p.ExpectedIntervals}
+	literals := make([]string, len(fields))
+	for i, field := range fields {
+		literal, err := formatValue(field)
+		if err != nil {
+			return "", err
+		}
+		literals[i] = literal
+	}
+	// The VALUES list has no column types, so the period start needs a cast
+	literals[2] += "::timestamp"
+	return "(" + strings.Join(literals, ", ") + ")", nil
+}
+
+// GenerateRollupDDL returns the statements that create the hourly, daily and
+// monthly rollup tables, keyed on NMI, suffix and the start of the period.
+func GenerateRollupDDL(opts ...Option) string {
+	o := newOptions(opts)
+	consumptionType := "numeric"
+	if o.wattHours {
+		consumptionType = "bigint"
+	}
+
+	tables := []postgres.Table{o.meterReadingsHourlyTable(), o.meterReadingsDailyTable(), o.meterReadingsMonthlyTable()}
+
+	var b strings.Builder
+	for i, t := range tables {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		name := t.TableName()
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", qualifiedName(t.SchemaName(), name))
+		b.WriteString("    nmi varchar(10) NOT NULL,\n")
+		b.WriteString("    nmi_suffix varchar(2) NOT NULL,\n")
+		b.WriteString("    period_start timestamp NOT NULL,\n")
+		fmt.Fprintf(&b, "    consumption %s NOT NULL,\n", consumptionType)
+		b.WriteString("    interval_count integer NOT NULL,\n")
+		b.WriteString("    expected_intervals integer NOT NULL,\n")
+		b.WriteString("    completeness numeric(5, 4) NOT NULL,\n")
+		fmt.Fprintf(&b, "    CONSTRAINT %s PRIMARY KEY (nmi, nmi_suffix, period_start)\n", quoteIdentifier(name+"_pk"))
+		b.WriteString(");\n")
+	}
+
+	return b.String()
+}
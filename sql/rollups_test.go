@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateRollupBatches(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	readings := dayOfReadings("NMI1", "E1", march1)
+	readings = append(readings, dayOfReadings("NMI2", "E1", march1)...)
+	for i := range readings {
+		readings[i].IntervalLength = 30
+	}
+
+	batches, err := GenerateRollupBatches(readings, 48, WithWattHours())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("Expected a batch per NMI, but got %d", len(batches))
+	}
+
+	// Totals are recomputed from the stored readings, not taken from the batch
+	sql := batches[0].Statement
+	for _, expected := range []string{
+		"INSERT INTO public.meter_readings_hourly",
+		"INSERT INTO public.meter_readings_daily",
+		"INSERT INTO public.meter_readings_monthly",
+		"JOIN public.meter_readings r ON r.nmi = p.nmi AND r.nmi_suffix = p.nmi_suffix",
+		`r."timestamp" > p.period_start AND r."timestamp" <= p.period_start + interval '1 hour'`,
+		"sum(r.consumption), count(*)",
+		"GROUP BY p.nmi, p.nmi_suffix, p.period_start, p.expected_intervals",
+		"ON CONFLICT (nmi, nmi_suffix, period_start) DO UPDATE",
+		"('NMI1', 'E1', '2005-03-01 01:00:00'::timestamp, 2)",
+		"('NMI1', 'E1', '2005-03-01 00:00:00'::timestamp, 48)",
+		"('NMI1', 'E1', '2005-03-01 00:00:00'::timestamp, 1488)",
+	} {
+		if !strings.Contains(sql, expected) {
+			t.Errorf("SQL doesn't contain %s:\n%.2000s", expected, sql)
+		}
+	}
+	if strings.Contains(sql, "24000") || strings.Contains(sql, "NMI2") {
+		t.Errorf("Expected only NMI1's periods, without totals from the batch:\n%.2000s", sql)
+	}
+	// 24 hours, a day and a month
+	if batches[0].Rows != 26 {
+		t.Errorf("Expected 26 rollup rows, but got %d", batches[0].Rows)
+	}
+}
+
+func TestGenerateRollupBatchesDayArrays(t *testing.T) {
+	readings := dayOfReadings("NMI1", "E1", time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	batches, err := GenerateRollupBatches(readings, 48, WithSchema("billing"), WithDayArrays())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, but got %d", len(batches))
+	}
+
+	sql := batches[0].Statement
+	if count := strings.Count(sql, "JOIN billing.meter_reading_days_unnested r"); count != 3 {
+		t.Errorf("Expected each rollup to read the unnested view, but got %d:\n%.2000s", count, sql)
+	}
+	if strings.Contains(sql, "JOIN billing.meter_readings ") {
+		t.Errorf("Expected no rollup to read meter_readings:\n%.2000s", sql)
+	}
+}
+
+func TestGenerateRollupBatchesMaxSize(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	var readings []csv.Reading
+	for day := 0; day < 4; day++ {
+		readings = append(readings, dayOfReadings("NMI1", "E1", march1.AddDate(0, 0, day))...)
+	}
+	for i := range readings {
+		readings[i].IntervalLength = 30
+	}
+
+	const maxSize = 4000
+	batches, err := GenerateRollupBatches(readings, 0, WithMaxStatementSize(maxSize))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(batches) < 2 {
+		t.Fatalf("Expected the rollups to be split to fit %d bytes, but got %d batch", maxSize, len(batches))
+	}
+	for i, batch := range batches {
+		if len(batch.Statement) > maxSize {
+			t.Errorf("Batch %d is %d bytes, more than %d", i, len(batch.Statement), maxSize)
+		}
+	}
+}
+
+func TestGenerateRollupDDL(t *testing.T) {
+	ddl := GenerateRollupDDL(WithTablePrefix("tenant1_"))
+
+	for _, period := range []string{"hourly", "daily", "monthly"} {
+		name := "tenant1_meter_readings_" + period
+		if !strings.Contains(ddl, "CREATE TABLE IF NOT EXISTS public."+name+" (") {
+			t.Errorf("DDL doesn't create %s:\n%s", name, ddl)
+		}
+		if !strings.Contains(ddl, "CONSTRAINT "+name+"_pk PRIMARY KEY (nmi, nmi_suffix, period_start)") {
+			t.Errorf("DDL doesn't key %s on NMI, suffix and period:\n%s", name, ddl)
+		}
+	}
+}
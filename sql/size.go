@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"strings"
+)
+
+// rowSeparator is what separates the rows of a multi-row VALUES list.
+const rowSeparator = ",\n       "
+
+// splitBySize packs readings into chunks whose INSERT statements are estimated
+// to fit in o.maxStatementSize bytes. If batchSize is positive, a chunk also
+// holds no more than batchSize readings.
+func splitBySize(readings []csv.Reading, batchSize int, o options) ([][]csv.Reading, error) {
+	if len(readings) == 0 {
+		return nil, nil
+	}
+
+	// The statement for a single row is the fixed overhead plus that row
+	first, err := rowLiteral(readings[0], o)
+	if err != nil {
+		return nil, err
+	}
+	single, err := generateBatchStatement(readings[:1], o)
+	if err != nil {
+		return nil, err
+	}
+	overhead := int64(len(single) - len(first))
+
+	var chunks [][]csv.Reading
+	start := 0
+	size := overhead
+	for i, reading := range readings {
+		row, err := rowLiteral(reading, o)
+		if err != nil {
+			return nil, err
+		}
+		rowSize := int64(len(row))
+		if i > start {
+			rowSize += int64(len(rowSeparator))
+		}
+
+		full := batchSize > 0 && i-start == batchSize
+		if i > start && (size+rowSize > o.maxStatementSize || full) {
+			chunks = append(chunks, readings[start:i])
+			start = i
+			size = overhead
+			rowSize = int64(len(row))
+		}
+		size += rowSize
+	}
+	chunks = append(chunks, readings[start:])
+
+	return chunks, nil
+}
+
+// generateSizedBatches generates the chunks and splits any whose statement
+// still comes out larger than maxSize, so the estimate in splitBySize never
+// has to be exact.
+func generateSizedBatches(chunks [][]csv.Reading, maxSize int64, generate func([]csv.Reading) (Batch, error)) ([]Batch, error) {
+	batches, err := generateBatches(chunks, generate)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Batch
+	for i, batch := range batches {
+		if int64(len(batch.Statement)) <= maxSize {
+			results = append(results, batch)
+			continue
+		}
+		if len(chunks[i]) == 1 {
+			return nil, fmt.Errorf("statement for a single reading is %d bytes, more than the maximum of %d", len(batch.Statement), maxSize)
+		}
+
+		half := len(chunks[i]) / 2
+		split, err := generateSizedBatches([][]csv.Reading{chunks[i][:half], chunks[i][half:]}, maxSize, generate)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, split...)
+	}
+
+	return results, nil
+}
+
+// rowLiteral renders the VALUES tuple a reading is inserted as.
+func rowLiteral(reading csv.Reading, o options) (string, error) {
+	values := []interface{}{reading.Nmi, reading.NmiSuffix, reading.Timestamp, reading.Consumption}
+	if o.deterministicIDs {
+		values = append([]interface{}{ReadingID(reading)}, values...)
+	}
+	if o.wattHours {
+		wh, err := toWattHours(reading)
+		if err != nil {
+			return "", err
+		}
+		values[len(values)-1] = wh
+	}
+
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literal, err := formatValue(value)
+		if err != nil {
+			return "", err
+		}
+		literals[i] = literal
+	}
+	return "(" + strings.Join(literals, ", ") + ")", nil
+}
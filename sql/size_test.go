@@ -0,0 +1,77 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGenerateInsertBatchesMaxStatementSize(t *testing.T) {
+	readings := make([]csv.Reading, 500)
+	for i := range readings {
+		// Vary the value length so rows differ in size
+		readings[i] = csv.Reading{
+			MeterReadings: model.MeterReadings{
+				Nmi:         "NMI1",
+				NmiSuffix:   "E1",
+				Timestamp:   time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * 5 * time.Minute),
+				Consumption: decimal.New(int64(i*i*i), -3),
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		batchSize int
+		maxSize   int64
+		opts      []Option
+	}{
+		{name: "Size only", maxSize: 4096},
+		{name: "Size with IDs", maxSize: 4096, opts: []Option{WithDeterministicIDs()}},
+		{name: "Size and count", batchSize: 10, maxSize: 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append(tt.opts, WithMaxStatementSize(tt.maxSize))
+			batches, err := GenerateInsertBatches(readings, tt.batchSize, opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			rows := 0
+			for _, batch := range batches {
+				if int64(len(batch.Statement)) > tt.maxSize {
+					t.Errorf("Statement is %d bytes, more than %d", len(batch.Statement), tt.maxSize)
+				}
+				if tt.batchSize > 0 && batch.Rows > tt.batchSize {
+					t.Errorf("Batch has %d rows, more than %d", batch.Rows, tt.batchSize)
+				}
+				rows += batch.Rows
+			}
+			if rows != len(readings) {
+				t.Errorf("Expected %d rows across batches, but got %d", len(readings), rows)
+			}
+
+			// Packing should come close to the limit rather than splitting early
+			if tt.batchSize == 0 && int64(len(batches[0].Statement)) < tt.maxSize*3/4 {
+				t.Errorf("First statement is only %d bytes of %d", len(batches[0].Statement), tt.maxSize)
+			}
+		})
+	}
+}
+
+func TestGenerateInsertBatchesMaxStatementSizeTooSmall(t *testing.T) {
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.5")}},
+	}
+
+	_, err := GenerateInsertBatches(readings, 0, WithMaxStatementSize(10))
+	if err == nil || !strings.Contains(err.Error(), "single reading") {
+		t.Errorf("Expected an error for a limit smaller than one reading, but got: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"strings"
+)
+
+const stagingSuffix = "_staging"
+
+// GenerateStagingBatches returns the batches that load a run through a staging
+// table, when the options select a staging merge: open recreates the staging
+// table, and goes before every batch of readings, which insert into it; merge
+// copies the staged readings into the target table and drops the staging table,
+// and goes after them. The staging table is an unlogged table next to the
+// target, rather than a temporary one, as each file may run in its own session.
+// Both are nil without a staging merge.
+func GenerateStagingBatches(opts ...Option) (open, merge []Batch) {
+	o := newOptions(opts)
+	if !o.stagingMerge {
+		return nil, nil
+	}
+
+	target := o.meterReadingsTable()
+	stagingOptions := o
+	stagingOptions.staging = true
+	staging := stagingOptions.meterReadingsTable()
+	targetName := qualifiedName(target.SchemaName(), target.TableName())
+	stagingName := qualifiedName(staging.SchemaName(), staging.TableName())
+
+	// A failed run leaves its staging table behind, so a rerun starts afresh
+	var b strings.Builder
+	fmt.Fprintf(&b, "DROP TABLE IF EXISTS %s;\n", stagingName)
+	fmt.Fprintf(&b, "CREATE UNLOGGED TABLE %s (LIKE %s INCLUDING DEFAULTS);\n", stagingName, targetName)
+	open = []Batch{newBatch(b.String(), nil)}
+
+	b.Reset()
+	if o.replace {
+		b.WriteString(replaceStagedDays(targetName, stagingName))
+	}
+	columns := insertColumns(o)
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = quoteIdentifier(column.Name())
+	}
+	columnList := strings.Join(names, ", ")
+	fmt.Fprintf(&b, "INSERT INTO %s (%s)\nSELECT %s FROM %s\nON CONFLICT (nmi, nmi_suffix, \"timestamp\") DO NOTHING;\n", targetName, columnList, columnList, stagingName)
+	fmt.Fprintf(&b, "DROP TABLE %s;\n", stagingName)
+	merge = []Batch{newBatch(b.String(), nil)}
+
+	return open, merge
+}
+
+// replaceStagedDays deletes the target table's readings for every NMI, suffix
+// and day that has a staged reading, so replace mode takes effect in the merge.
+// As in generateDeleteStatement, a day runs from just after midnight up to and
+// including the following midnight.
+func replaceStagedDays(targetName, stagingName string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "DELETE FROM %s t\n", targetName)
+	fmt.Fprintf(&b, "USING (SELECT DISTINCT nmi, nmi_suffix, date_trunc('day', \"timestamp\" - interval '1 microsecond') AS day FROM %s) s\n", stagingName)
+	b.WriteString("WHERE t.nmi = s.nmi AND t.nmi_suffix = s.nmi_suffix AND t.\"timestamp\" > s.day AND t.\"timestamp\" <= s.day + interval '1 day';\n")
+	return b.String()
+}
+
+// generateStagingInsertStatement inserts a batch into the staging table, which
+// GenerateStagingBatches creates and merges.
+func generateStagingInsertStatement(batch []csv.Reading, o options) (string, error) {
+	stagingOptions := o
+	stagingOptions.staging = true
+	return generateBatchInsertStatement(batch, stagingOptions)
+}
@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestGenerateInsertBatchesStagingMerge(t *testing.T) {
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.5")}},
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2023, 5, 1, 1, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("2.5")}},
+	}
+
+	batches, err := GenerateInsertBatches(readings, 1, WithStagingMerge(), WithSchema("billing"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 batches, but got %d", len(batches))
+	}
+
+	// Every batch only inserts into the staging table
+	for i, batch := range batches {
+		if !strings.HasPrefix(batch.Statement, "INSERT INTO billing.meter_readings_staging") || strings.Contains(batch.Statement, "ON CONFLICT") {
+			t.Errorf("Expected batch %d to insert into the staging table alone:\n%s", i, batch.Statement)
+		}
+	}
+	if !strings.Contains(batches[0].Statement, "'NMI1', 'E1', '2023-05-01 00:30:00', 1.5") {
+		t.Errorf("Expected the first reading in the first batch:\n%s", batches[0].Statement)
+	}
+
+	open, merge := GenerateStagingBatches(WithStagingMerge(), WithSchema("billing"))
+	if len(open) != 1 || len(merge) != 1 {
+		t.Fatalf("Expected a batch to open and one to merge, but got %d and %d", len(open), len(merge))
+	}
+	assertSteps(t, open[0].Statement, []string{
+		"DROP TABLE IF EXISTS billing.meter_readings_staging;",
+		"CREATE UNLOGGED TABLE billing.meter_readings_staging (LIKE billing.meter_readings INCLUDING DEFAULTS);",
+	})
+	assertSteps(t, merge[0].Statement, []string{
+		`INSERT INTO billing.meter_readings (nmi, nmi_suffix, "timestamp", consumption)`,
+		`SELECT nmi, nmi_suffix, "timestamp", consumption FROM billing.meter_readings_staging`,
+		`ON CONFLICT (nmi, nmi_suffix, "timestamp") DO NOTHING;`,
+		"DROP TABLE billing.meter_readings_staging;",
+	})
+	if strings.Contains(merge[0].Statement, "DELETE") {
+		t.Errorf("Expected no DELETE without replace mode:\n%s", merge[0].Statement)
+	}
+
+	if open, merge := GenerateStagingBatches(WithSchema("billing")); open != nil || merge != nil {
+		t.Errorf("Expected no staging batches without a staging merge")
+	}
+	if _, err := GenerateParameterizedBatches(readings, 10, WithStagingMerge()); err == nil {
+		t.Errorf("Expected an error for parameterized staging merge, but got none")
+	}
+}
+
+func TestGenerateStagingBatchesReplace(t *testing.T) {
+	readings := dayOfReadings("NMI1", "E1", time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	batches, err := GenerateInsertBatches(readings, 100, WithStagingMerge(), WithReplace())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, batch := range batches {
+		if strings.Contains(batch.Statement, "DELETE") {
+			t.Errorf("Expected batch %d to leave the deletes to the merge:\n%s", i, batch.Statement)
+		}
+	}
+
+	_, merge := GenerateStagingBatches(WithStagingMerge(), WithReplace())
+	assertSteps(t, merge[0].Statement, []string{
+		"DELETE FROM public.meter_readings t",
+		`date_trunc('day', "timestamp" - interval '1 microsecond') AS day FROM public.meter_readings_staging`,
+		`t."timestamp" > s.day AND t."timestamp" <= s.day + interval '1 day';`,
+		"INSERT INTO public.meter_readings",
+		"DROP TABLE public.meter_readings_staging;",
+	})
+}
+
+// assertSteps checks that each step appears in statement, in order.
+func assertSteps(t *testing.T, statement string, steps []string) {
+	t.Helper()
+	position := 0
+	for _, step := range steps {
+		index := strings.Index(statement[position:], step)
+		if index < 0 {
+			t.Fatalf("Statement doesn't contain %q after position %d:\n%s", step, position, statement)
+		}
+		position += index + len(step)
+	}
+}
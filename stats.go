@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// channelStats summarises the readings of one NMI and suffix.
+type channelStats struct {
+	nmi, suffix, uom string
+	intervalLength   int32
+	readings         int
+	from, to         time.Time
+	total            decimal.Decimal
+}
+
+// runStats prints a summary of each NMI and suffix in a NEM12 file.
+func runStats(args []string) error {
+	fs := newFlagSet("stats", "Summarises the readings of each NMI and suffix in a NEM12 file.")
+	filename := fs.String("file", "", "CSV file to read")
+	fs.Parse(args)
+
+	readings, err := readFile(filename, fs.Usage)
+	if err != nil {
+		return err
+	}
+
+	channels := summariseChannels(readings)
+	nmis := make(map[string]bool)
+	for _, c := range channels {
+		nmis[c.nmi] = true
+	}
+	fmt.Printf("Readings: %d\nNMIs: %d\nChannels: %d\n", len(readings), len(nmis), len(channels))
+	if len(readings) == 0 {
+		return nil
+	}
+
+	from, to := channels[0].from, channels[0].to
+	for _, c := range channels {
+		if c.from.Before(from) {
+			from = c.from
+		}
+		if c.to.After(to) {
+			to = c.to
+		}
+	}
+	fmt.Printf("Intervals: %s to %s\n\n", from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NMI\tSUFFIX\tUOM\tINTERVAL\tREADINGS\tFROM\tTO\tTOTAL")
+	for _, c := range channels {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%dm\t%d\t%s\t%s\t%s\n", c.nmi, c.suffix, c.uom, c.intervalLength,
+			c.readings, c.from.Format(time.RFC3339), c.to.Format(time.RFC3339), c.total)
+	}
+	return w.Flush()
+}
+
+// summariseChannels returns the stats of each NMI and suffix, sorted by both.
+// An interval runs from its length before its timestamp up to the timestamp.
+func summariseChannels(readings []csv.Reading) []*channelStats {
+	byChannel := make(map[string]*channelStats)
+	var channels []*channelStats
+	for _, reading := range readings {
+		start := reading.Timestamp.Add(-time.Duration(reading.IntervalLength) * time.Minute)
+		key := reading.Nmi + "|" + reading.NmiSuffix
+		c, ok := byChannel[key]
+		if !ok {
+			c = &channelStats{
+				nmi:            reading.Nmi,
+				suffix:         reading.NmiSuffix,
+				uom:            reading.Uom,
+				intervalLength: reading.IntervalLength,
+				from:           start,
+				to:             reading.Timestamp,
+			}
+			byChannel[key] = c
+			channels = append(channels, c)
+		}
+		c.readings++
+		c.total = c.total.Add(reading.Consumption)
+		if start.Before(c.from) {
+			c.from = start
+		}
+		if reading.Timestamp.After(c.to) {
+			c.to = reading.Timestamp
+		}
+	}
+
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].nmi != channels[j].nmi {
+			return channels[i].nmi < channels[j].nmi
+		}
+		return channels[i].suffix < channels[j].suffix
+	})
+	return channels
+}
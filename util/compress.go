@@ -0,0 +1,83 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats for statement files.
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// compressionExts are the file extensions and decompression commands of each
+// compression format.
+var compressionExts = map[string]struct{ ext, decompress string }{
+	CompressionGzip: {".gz", "gzip"},
+	CompressionZstd: {".zst", "zstd"},
+}
+
+// ValidateCompression checks that format is a supported compression format and
+// that level is valid for it. A level of 0 means the format's default.
+func ValidateCompression(format string, level int) error {
+	switch format {
+	case CompressionGzip:
+		if level < 0 || level > gzip.BestCompression {
+			return fmt.Errorf("gzip compression level must be between 1 and %d, or 0 for the default", gzip.BestCompression)
+		}
+	case CompressionZstd:
+		if level < 0 || level > 22 {
+			return fmt.Errorf("zstd compression level must be between 1 and 22, or 0 for the default")
+		}
+	default:
+		return fmt.Errorf("unknown compression format %q, use gzip or zstd", format)
+	}
+	return nil
+}
+
+// compress compresses content in format at level, where a level of 0 means the
+// format's default.
+func compress(format string, level int, content []byte) ([]byte, error) {
+	if err := ValidateCompression(format, level); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		w, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+	case CompressionZstd:
+		encoderLevel := zstd.SpeedDefault
+		if level != 0 {
+			encoderLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		w, err := zstd.NewWriter(&buf, zstd.WithEncoderLevel(encoderLevel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+		if _, err := w.Write(content); err != nil {
+			w.Close()
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress: %v", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestValidateCompression(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		level       int
+		expectError bool
+	}{
+		{name: "gzip default level", format: "gzip"},
+		{name: "gzip best compression", format: "gzip", level: 9},
+		{name: "gzip level too high", format: "gzip", level: 10, expectError: true},
+		{name: "zstd default level", format: "zstd"},
+		{name: "zstd highest level", format: "zstd", level: 22},
+		{name: "zstd negative level", format: "zstd", level: -1, expectError: true},
+		{name: "Unknown format", format: "bzip2", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCompression(tt.format, tt.level)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected an error, but got none")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	content := bytes.Repeat([]byte("INSERT INTO meter_readings VALUES ('NEM1201009', 'E1');\n"), 100)
+
+	tests := []struct {
+		name       string
+		format     string
+		level      int
+		decompress func(io.Reader) (io.Reader, error)
+	}{
+		{
+			name:   "gzip",
+			format: "gzip",
+			level:  9,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			name:   "zstd",
+			format: "zstd",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return zstd.NewReader(r)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := compress(tt.format, tt.level, content)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(compressed) >= len(content) {
+				t.Errorf("Expected compressed size under %d bytes, but got %d", len(content), len(compressed))
+			}
+
+			r, err := tt.decompress(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			decompressed, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !bytes.Equal(decompressed, content) {
+				t.Errorf("Decompressed content doesn't match the original")
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"flo_energy_take_home/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// JSONLinesSchemaVersion is the version of the JSON Lines record schema. It is
+// increased whenever a field is removed or changes meaning; adding a field does
+// not change it.
+const JSONLinesSchemaVersion = 1
+
+// ReadingRecord is the JSON Lines record of one interval reading. Its timestamp
+// is the instant the interval ends, with the NEM time offset.
+type ReadingRecord struct {
+	SchemaVersion  int         `json:"schema_version"`
+	Type           string      `json:"type"`
+	Nmi            string      `json:"nmi"`
+	NmiSuffix      string      `json:"nmi_suffix"`
+	Uom            string      `json:"uom"`
+	IntervalLength int32       `json:"interval_length"`
+	Timestamp      time.Time   `json:"timestamp"`
+	Consumption    json.Number `json:"consumption"`
+	QualityMethod  string      `json:"quality_method"`
+}
+
+// DayRecord is the JSON Lines record of one NMI, suffix and day of intervals.
+// Consumption and QualityMethod have an element per interval of the day, which
+// is null where the interval was blank.
+type DayRecord struct {
+	SchemaVersion  int            `json:"schema_version"`
+	Type           string         `json:"type"`
+	Nmi            string         `json:"nmi"`
+	NmiSuffix      string         `json:"nmi_suffix"`
+	Uom            string         `json:"uom"`
+	IntervalLength int32          `json:"interval_length"`
+	ReadingDate    string         `json:"reading_date"`
+	Consumption    []*json.Number `json:"consumption"`
+	QualityMethod  []*string      `json:"quality_method"`
+}
+
+// WriteJSONLines writes a ReadingRecord per reading to w, or a DayRecord per NMI,
+// suffix and day when days is set.
+func WriteJSONLines(w io.Writer, readings []csv.Reading, days bool) error {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	encoder.SetEscapeHTML(false)
+
+	if days {
+		records, err := dayRecords(readings)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("failed to encode record: %v", err)
+			}
+		}
+	} else {
+		for _, reading := range readings {
+			record := ReadingRecord{
+				SchemaVersion:  JSONLinesSchemaVersion,
+				Type:           "reading",
+				Nmi:            reading.Nmi,
+				NmiSuffix:      reading.NmiSuffix,
+				Uom:            reading.Uom,
+				IntervalLength: reading.IntervalLength,
+				Timestamp:      csv.InNEMTime(reading.Timestamp),
+				Consumption:    json.Number(reading.Consumption.String()),
+				QualityMethod:  reading.QualityMethod,
+			}
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("failed to encode record: %v", err)
+			}
+		}
+	}
+
+	return buffered.Flush()
+}
+
+// dayRecords groups readings into a DayRecord per NMI, suffix and day, in order
+// of first appearance.
+func dayRecords(readings []csv.Reading) ([]*DayRecord, error) {
+	index := make(map[string]*DayRecord)
+	var records []*DayRecord
+
+	for _, reading := range readings {
+		if reading.IntervalLength <= 0 {
+			return nil, fmt.Errorf("reading for %s at %s has no interval length", reading.Nmi, reading.Timestamp)
+		}
+		day := csv.ReadingDay(reading.Timestamp)
+		key := reading.Nmi + "|" + reading.NmiSuffix + "|" + day.Format("2006-01-02")
+
+		record, ok := index[key]
+		if !ok {
+			numberOfIntervals := 1440 / int(reading.IntervalLength)
+			record = &DayRecord{
+				SchemaVersion:  JSONLinesSchemaVersion,
+				Type:           "day",
+				Nmi:            reading.Nmi,
+				NmiSuffix:      reading.NmiSuffix,
+				Uom:            reading.Uom,
+				IntervalLength: reading.IntervalLength,
+				ReadingDate:    day.Format("2006-01-02"),
+				Consumption:    make([]*json.Number, numberOfIntervals),
+				QualityMethod:  make([]*string, numberOfIntervals),
+			}
+			index[key] = record
+			records = append(records, record)
+		}
+		if reading.IntervalLength != record.IntervalLength {
+			return nil, fmt.Errorf("readings for %s on %s have mixed interval lengths", reading.Nmi, record.ReadingDate)
+		}
+
+		length := time.Duration(reading.IntervalLength) * time.Minute
+		slot := int(reading.Timestamp.Sub(day)/length) - 1
+		if slot < 0 || slot >= len(record.Consumption) || reading.Timestamp.Sub(day)%length != 0 {
+			return nil, fmt.Errorf("reading for %s at %s is not on a %d minute interval boundary", reading.Nmi, reading.Timestamp, reading.IntervalLength)
+		}
+		consumption := json.Number(reading.Consumption.String())
+		qualityMethod := reading.QualityMethod
+		record.Consumption[slot] = &consumption
+		record.QualityMethod[slot] = &qualityMethod
+	}
+
+	return records, nil
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"bytes"
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	// Parsed timestamps are the NEM time written in the file, labelled UTC
+	readings := []csv.Reading{
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("0.461")}, Uom: "kWh", IntervalLength: 30, QualityMethod: "A"},
+		{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: time.Date(2005, 3, 2, 0, 0, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1.250")}, Uom: "kWh", IntervalLength: 30, QualityMethod: "S53"},
+	}
+
+	tests := []struct {
+		name          string
+		days          bool
+		expectedLines []string
+	}{
+		{
+			name: "A record per reading",
+			expectedLines: []string{
+				`{"schema_version":1,"type":"reading","nmi":"NMI1","nmi_suffix":"E1","uom":"kWh","interval_length":30,"timestamp":"2005-03-01T00:30:00+10:00","consumption":0.461,"quality_method":"A"}`,
+				`{"schema_version":1,"type":"reading","nmi":"NMI1","nmi_suffix":"E1","uom":"kWh","interval_length":30,"timestamp":"2005-03-02T00:00:00+10:00","consumption":1.25,"quality_method":"S53"}`,
+			},
+		},
+		{
+			name: "A record per day",
+			days: true,
+			expectedLines: []string{
+				`{"schema_version":1,"type":"day","nmi":"NMI1","nmi_suffix":"E1","uom":"kWh","interval_length":30,"reading_date":"2005-03-01","consumption":[0.461,` + strings.Repeat("null,", 46) + `1.25],"quality_method":["A",` + strings.Repeat("null,", 46) + `"S53"]}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteJSONLines(&b, readings, tt.days); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			if len(lines) != len(tt.expectedLines) {
+				t.Fatalf("Expected %d lines, but got %d:\n%s", len(tt.expectedLines), len(lines), b.String())
+			}
+			for i, expected := range tt.expectedLines {
+				if lines[i] != expected {
+					t.Errorf("Expected line %d to be\n%s\nbut got\n%s", i+1, expected, lines[i])
+				}
+			}
+		})
+	}
+}
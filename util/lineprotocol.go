@@ -0,0 +1,75 @@
+package util
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lineProtocolMeasurement is the measurement readings are written to.
+const lineProtocolMeasurement = "consumption"
+
+// lineProtocolFileNames names the line protocol files, padded like the SQL files.
+var lineProtocolFileNames = FileNameTemplate{template: "readings_{seq}"}
+
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// WriteToLineProtocolFilesParallel writes the readings as InfluxDB line protocol,
+// batchSize readings to a file, numbered, zero-padded and written in parallel the
+// same way as WriteToSQLFilesParallel. A batchSize of zero or less writes a
+// single file.
+func WriteToLineProtocolFilesParallel(readings []csv.Reading, batchSize int, outputDir string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	if batchSize <= 0 {
+		batchSize = len(readings)
+	}
+	count := 0
+	if len(readings) > 0 {
+		count = (len(readings) + batchSize - 1) / batchSize
+	}
+
+	return writeFilesParallel(count, func(index int) error {
+		start := index * batchSize
+		end := start + batchSize
+		if end > len(readings) {
+			end = len(readings)
+		}
+
+		var b strings.Builder
+		for _, reading := range readings[start:end] {
+			b.WriteString(lineProtocol(reading))
+		}
+
+		name, err := lineProtocolFileNames.Name(FileNameFields{Seq: index + 1, Count: count})
+		if err != nil {
+			return err
+		}
+		return WriteFileAtomic(filepath.Join(outputDir, name+".lp"), []byte(b.String()), 0644)
+	})
+}
+
+// lineProtocol renders a reading as a line of InfluxDB line protocol. Empty tags
+// are left out, since line protocol does not allow them, and the timestamp is
+// the instant of the NEM time in the file.
+func lineProtocol(reading csv.Reading) string {
+	var b strings.Builder
+	b.WriteString(lineProtocolMeasurement)
+	for _, tag := range []struct{ key, value string }{
+		{"nmi", reading.Nmi},
+		{"suffix", reading.NmiSuffix},
+		{"uom", reading.Uom},
+	} {
+		if tag.value != "" {
+			b.WriteString("," + tag.key + "=" + tagEscaper.Replace(tag.value))
+		}
+	}
+	b.WriteString(" value=" + reading.Consumption.String())
+	b.WriteString(" " + strconv.FormatInt(csv.InNEMTime(reading.Timestamp).UnixNano(), 10) + "\n")
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteToLineProtocolFilesParallel(t *testing.T) {
+	timestamp := time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC)
+	readings := make([]csv.Reading, 5)
+	for i := range readings {
+		readings[i] = csv.Reading{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "E1", Timestamp: timestamp, Consumption: decimal.RequireFromString("0.461")}, Uom: "kWh"}
+	}
+	readings[4].NmiSuffix = "E 1,x=y"
+	readings[4].Uom = ""
+
+	dir := t.TempDir()
+	// A file left over from an earlier run, which is left alone
+	if err := os.WriteFile(filepath.Join(dir, "readings_9.lp"), nil, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := WriteToLineProtocolFilesParallel(readings, 2, dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "readings_*.lp"))
+	if len(files) != 4 {
+		t.Fatalf("Expected 4 files, but got %d", len(files))
+	}
+
+	first, err := os.ReadFile(filepath.Join(dir, "readings_1.lp"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "consumption,nmi=NMI1,suffix=E1,uom=kWh value=0.461 1109601000000000000\n"
+	if string(first) != strings.Repeat(expected, 2) {
+		t.Errorf("Expected\n%s\nbut got\n%s", strings.Repeat(expected, 2), first)
+	}
+
+	last, err := os.ReadFile(filepath.Join(dir, "readings_3.lp"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = `consumption,nmi=NMI1,suffix=E\ 1\,x\=y value=0.461 1109601000000000000` + "\n"
+	if string(last) != expected {
+		t.Errorf("Expected escaped tags and no empty uom tag\n%s\nbut got\n%s", expected, last)
+	}
+}
+
+func TestWriteToLineProtocolFilesParallelPadding(t *testing.T) {
+	readings := make([]csv.Reading, 12)
+	for i := range readings {
+		readings[i] = csv.Reading{MeterReadings: model.MeterReadings{Nmi: "NMI1", Timestamp: time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC), Consumption: decimal.RequireFromString("1")}}
+	}
+
+	dir := t.TempDir()
+	if err := WriteToLineProtocolFilesParallel(readings, 1, dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 12 files are numbered from 01, so they sort in order
+	for _, name := range []string{"readings_01.lp", "readings_09.lp", "readings_12.lp"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultFileNameTemplate is the name statement files are given, before their extension.
+const DefaultFileNameTemplate = "statement_{seq}"
+
+var placeholder = regexp.MustCompile(`\{([a-z_]+)(?::(\d+))?\}`)
+
+// FileNameTemplate names output files from placeholders: {seq} is the file's
+// sequence number, zero-padded to the width of the file count so that names
+// sort in order, or to N digits by {seq:N}, {run_id} the run ID, {source} the
+// input file name without its extension, and {partition} the partition key, if
+// any.
+type FileNameTemplate struct {
+	template string
+}
+
+// FileNameFields are the values a FileNameTemplate's placeholders are filled from.
+// Count is the number of files in the sequence, or 0 if it's not known.
+type FileNameFields struct {
+	Seq       int
+	Count     int
+	RunID     string
+	Source    string
+	Partition string
+}
+
+// ParseFileNameTemplate checks that template only uses known placeholders, and
+// includes {seq} so that every file gets a different name.
+func ParseFileNameTemplate(template string) (FileNameTemplate, error) {
+	hasSeq := false
+	for _, match := range placeholder.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "seq":
+			hasSeq = true
+		case "run_id", "source", "partition":
+			if match[2] != "" {
+				return FileNameTemplate{}, fmt.Errorf("invalid file name template %q: only {seq} can be padded", template)
+			}
+		default:
+			return FileNameTemplate{}, fmt.Errorf("invalid file name template %q: unknown placeholder {%s}", template, match[1])
+		}
+	}
+	if !hasSeq {
+		return FileNameTemplate{}, fmt.Errorf("invalid file name template %q: it must include {seq}", template)
+	}
+	if strings.ContainsAny(placeholder.ReplaceAllString(template, ""), "{}/\\") {
+		return FileNameTemplate{}, fmt.Errorf("invalid file name template %q: use only placeholders and plain characters", template)
+	}
+	return FileNameTemplate{template: template}, nil
+}
+
+// Name fills in the template's placeholders from fields, and fails if the result
+// is not a plain file name, or if {seq:N} is too narrow for fields.Count, as the
+// names would then sort out of order.
+func (t FileNameTemplate) Name(fields FileNameFields) (string, error) {
+	countWidth := 0
+	if fields.Count > 0 {
+		countWidth = len(strconv.Itoa(fields.Count))
+	}
+	for _, match := range placeholder.FindAllStringSubmatch(t.template, -1) {
+		if width, _ := strconv.Atoi(match[2]); match[1] == "seq" && match[2] != "" && width < countWidth {
+			return "", fmt.Errorf("file name template %q pads {seq} to %d digits, too few for %d files", t.template, width, fields.Count)
+		}
+	}
+
+	name := placeholder.ReplaceAllStringFunc(t.template, func(p string) string {
+		match := placeholder.FindStringSubmatch(p)
+		switch match[1] {
+		case "seq":
+			width := countWidth
+			if match[2] != "" {
+				width, _ = strconv.Atoi(match[2])
+			}
+			return fmt.Sprintf("%0*d", width, fields.Seq)
+		case "run_id":
+			return fields.RunID
+		case "source":
+			return fields.Source
+		default:
+			return fields.Partition
+		}
+	})
+	if !plainName.MatchString(name) {
+		return "", fmt.Errorf("file name template %q gives %q, which can't be used as a file name", t.template, name)
+	}
+	return name, nil
+}
+
+// SourceName returns the name of an input file without its directory or extension,
+// for the {source} placeholder.
+func SourceName(fileName string) string {
+	base := filepath.Base(fileName)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileNameTemplate(t *testing.T) {
+	fields := FileNameFields{Seq: 7, RunID: "20050301T120000.000Z", Source: "example", Partition: "NMI1"}
+
+	tests := []struct {
+		name        string
+		template    string
+		fields      FileNameFields
+		expected    string
+		expectError string
+	}{
+		{name: "Default", template: DefaultFileNameTemplate, fields: fields, expected: "statement_7"},
+		{name: "Zero-padded sequence", template: "statement_{seq:4}", fields: fields, expected: "statement_0007"},
+		{name: "Sequence wider than padding", template: "{seq:1}", fields: FileNameFields{Seq: 12}, expected: "12"},
+		{name: "Default padded to the file count", template: DefaultFileNameTemplate, fields: FileNameFields{Seq: 7, Count: 120}, expected: "statement_007"},
+		{name: "Padding wider than the file count", template: "{seq:4}", fields: FileNameFields{Seq: 7, Count: 120}, expected: "0007"},
+		{name: "Padding narrower than the file count", template: "{seq:2}", fields: FileNameFields{Seq: 7, Count: 120}, expectError: "too few for 120 files"},
+		{
+			name:     "All placeholders",
+			template: "{source}_{partition}_{run_id}_{seq:3}",
+			fields:   fields,
+			expected: "example_NMI1_20050301T120000.000Z_007",
+		},
+		{name: "Missing sequence", template: "{source}", expectError: "must include {seq}"},
+		{name: "Unknown placeholder", template: "{seq}_{date}", expectError: "unknown placeholder {date}"},
+		{name: "Padded run ID", template: "{run_id:4}_{seq}", expectError: "only {seq} can be padded"},
+		{name: "Directory in template", template: "sql/{seq}", expectError: "plain characters"},
+		{name: "Empty partition", template: "{partition}{seq}", fields: FileNameFields{Seq: 1}, expected: "1"},
+		{name: "Source with spaces", template: "{source}_{seq}", fields: FileNameFields{Seq: 1, Source: "my file"}, expectError: "can't be used as a file name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := ParseFileNameTemplate(tt.template)
+			var name string
+			if err == nil {
+				name, err = template.Name(tt.fields)
+			}
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected an error containing %q, but got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if name != tt.expected {
+				t.Errorf("Expected %q, but got %q", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	if name := SourceName("data/2005/example.csv"); name != "example" {
+		t.Errorf("Expected example, but got %q", name)
+	}
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// plainName matches names that are safe to use as a file or directory name.
+var plainName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// NewRunID returns a run ID for a run started at now, such as 20050301T120000.000Z.
+func NewRunID(now time.Time) string {
+	return now.UTC().Format("20060102T150405.000Z")
+}
+
+// PrepareRunDir creates outputDir/runID for a run to write its output into, and
+// returns its path. It fails if the directory already exists, unless overwrite
+// is set, in which case the directory and everything in it is replaced.
+func PrepareRunDir(outputDir, runID string, overwrite bool) (string, error) {
+	if !plainName.MatchString(runID) {
+		return "", fmt.Errorf("invalid run ID %q: use letters, digits, '.', '_' and '-'", runID)
+	}
+	dir := filepath.Join(outputDir, runID)
+
+	if _, err := os.Stat(dir); err == nil {
+		if !overwrite {
+			return "", fmt.Errorf("output directory %s already exists, use --overwrite to replace it", dir)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return "", fmt.Errorf("failed to remove output directory %s: %v", dir, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to check output directory %s: %v", dir, err)
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return dir, nil
+}
+
+// AtomicFile is a file written under a temporary name in the directory of its
+// final name, and only renamed to it by Commit, so a crash never leaves a
+// partly written file under the final name.
+type AtomicFile struct {
+	*os.File
+	name string
+	perm os.FileMode
+}
+
+// CreateAtomic creates an AtomicFile that Commit renames to fileName with perm.
+func CreateAtomic(fileName string, perm os.FileMode) (*AtomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %s: %v", fileName, err)
+	}
+	return &AtomicFile{File: file, name: fileName, perm: perm}, nil
+}
+
+// Commit flushes the file to disk and renames it to its final name.
+func (f *AtomicFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return fmt.Errorf("failed to write file %s: %v", f.name, err)
+	}
+	if err := f.Close(); err != nil {
+		f.Abort()
+		return fmt.Errorf("failed to write file %s: %v", f.name, err)
+	}
+	if err := os.Chmod(f.Name(), f.perm); err != nil {
+		f.Abort()
+		return fmt.Errorf("failed to write file %s: %v", f.name, err)
+	}
+	if err := os.Rename(f.Name(), f.name); err != nil {
+		f.Abort()
+		return fmt.Errorf("failed to write file %s: %v", f.name, err)
+	}
+	return nil
+}
+
+// Abort discards the temporary file. It does nothing once the file is committed,
+// so it can be deferred.
+func (f *AtomicFile) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// WriteFileAtomic is like os.WriteFile, but writes through an AtomicFile.
+func WriteFileAtomic(fileName string, content []byte, perm os.FileMode) error {
+	file, err := CreateAtomic(fileName, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Abort()
+		return fmt.Errorf("failed to write file %s: %v", fileName, err)
+	}
+	return file.Commit()
+}
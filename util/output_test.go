@@ -0,0 +1,124 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRunID(t *testing.T) {
+	now := time.Date(2005, 3, 1, 22, 0, 0, 123e6, time.FixedZone("AEST", 10*60*60))
+	if id := NewRunID(now); id != "20050301T120000.123Z" {
+		t.Errorf("Unexpected run ID %q", id)
+	}
+}
+
+func TestPrepareRunDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		runID       string
+		existing    bool
+		overwrite   bool
+		expectError string
+	}{
+		{name: "New directory", runID: "run-1"},
+		{name: "Existing directory", runID: "run-1", existing: true, expectError: "already exists"},
+		{name: "Overwrite existing directory", runID: "run-1", existing: true, overwrite: true},
+		{name: "Run ID escaping the output directory", runID: "../run-1", expectError: "invalid run ID"},
+		{name: "Empty run ID", runID: "", expectError: "invalid run ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			old := filepath.Join(outputDir, tt.runID, "statement_1.sql")
+			if tt.existing {
+				if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if err := os.WriteFile(old, []byte("old content"), 0644); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			dir, err := PrepareRunDir(outputDir, tt.runID, tt.overwrite)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected an error containing %q, but got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if dir != filepath.Join(outputDir, tt.runID) {
+				t.Errorf("Unexpected run directory %s", dir)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("Expected an empty run directory, but got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "load.sh")
+	if err := os.WriteFile(fileName, []byte("old content"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := WriteFileAtomic(fileName, []byte("new content"), 0755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(content) != "new content" {
+		t.Errorf("Unexpected content %q", content)
+	}
+	info, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755, but got %v", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the written file, but got %d files", len(entries))
+	}
+}
+
+func TestAtomicFileAbort(t *testing.T) {
+	dir := t.TempDir()
+	file, err := CreateAtomic(filepath.Join(dir, "readings.xlsx"), 0644)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := file.Write([]byte("partial")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file.Abort()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no files after aborting, but got %d", len(entries))
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as 512KB, 50MB or 1GB. Units are powers of
+// 1024, and a plain number is taken as bytes.
+func ParseByteSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a positive number of B, KB, MB or GB", size)
+	}
+	return n * multiplier, nil
+}
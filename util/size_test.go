@@ -0,0 +1,45 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int64
+		expectError bool
+	}{
+		{input: "1024", expected: 1024},
+		{input: "100B", expected: 100},
+		{input: "512KB", expected: 512 * 1024},
+		{input: "50MB", expected: 50 * 1024 * 1024},
+		{input: "50mb", expected: 50 * 1024 * 1024},
+		{input: "2 GB", expected: 2 * 1024 * 1024 * 1024},
+		{input: "", expectError: true},
+		{input: "MB", expectError: true},
+		{input: "-5MB", expectError: true},
+		{input: "1.5MB", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseByteSize(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, but got %d", result)
+				} else if strings.HasPrefix(err.Error(), "error:") {
+					t.Errorf("Expected no error: prefix, but got %q", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %d, but got %d", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"bufio"
+	"flo_energy_take_home/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WriteSQLStream writes every batch to w in order, each wrapped in a transaction
+// as in a statement file, for piping straight into psql.
+func WriteSQLStream(w io.Writer, batches []sql.Batch) error {
+	buf := bufio.NewWriter(w)
+	for _, batch := range batches {
+		if _, err := buf.WriteString(wrapInTransaction(batch.Statement)); err != nil {
+			return fmt.Errorf("failed to write statements: %v", err)
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write statements: %v", err)
+	}
+	return nil
+}
+
+// WriteSQLFile is like WriteSQLStream, but writes to a single file, atomically.
+// It fails if the file already exists, unless overwrite is set.
+func WriteSQLFile(batches []sql.Batch, fileName string, overwrite bool) error {
+	if _, err := os.Stat(fileName); err == nil {
+		if !overwrite {
+			return fmt.Errorf("output file %s already exists, use --overwrite to replace it", fileName)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check output file %s: %v", fileName, err)
+	}
+
+	file, err := CreateAtomic(fileName, 0644)
+	if err != nil {
+		return err
+	}
+	if err := WriteSQLStream(file, batches); err != nil {
+		file.Abort()
+		return fmt.Errorf("failed to write file %s: %v", fileName, err)
+	}
+	return file.Commit()
+}
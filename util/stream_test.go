@@ -0,0 +1,77 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"flo_energy_take_home/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestWriteSQLStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		batches  []sql.Batch
+		expected string
+	}{
+		{
+			name: "Batches in order",
+			batches: []sql.Batch{
+				{Statement: "INSERT INTO table1 VALUES (1, 'test1');\n"},
+				{Statement: "INSERT INTO table1 VALUES (2, 'test2')"},
+			},
+			expected: "BEGIN;\nINSERT INTO table1 VALUES (1, 'test1');\nCOMMIT;\n" +
+				"BEGIN;\nINSERT INTO table1 VALUES (2, 'test2');\nCOMMIT;\n",
+		},
+		{
+			name:     "No batches",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteSQLStream(&buf, tt.batches); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, but got %q", tt.expected, buf.String())
+			}
+		})
+	}
+
+	if err := WriteSQLStream(failingWriter{}, []sql.Batch{{Statement: "SELECT 1"}}); err == nil {
+		t.Errorf("Expected an error writing to a broken stream, but got none")
+	}
+}
+
+func TestWriteSQLFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "readings.sql")
+	batches := []sql.Batch{{Statement: "SELECT 1"}}
+
+	if err := WriteSQLFile(batches, fileName, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := WriteSQLFile([]sql.Batch{{Statement: "SELECT 2"}}, fileName, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for an existing file, but got: %v", err)
+	}
+	if content, _ := os.ReadFile(fileName); !strings.Contains(string(content), "SELECT 1") {
+		t.Errorf("Expected the existing file to be kept, but got %q", content)
+	}
+
+	if err := WriteSQLFile([]sql.Batch{{Statement: "SELECT 2"}}, fileName, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(fileName); !strings.Contains(string(content), "SELECT 2") {
+		t.Errorf("Expected the file to be replaced, but got %q", content)
+	}
+}
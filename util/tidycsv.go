@@ -0,0 +1,46 @@
+package util
+
+import (
+	stdcsv "encoding/csv"
+	"flo_energy_take_home/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TidyCSVHeader is the header row of a tidy CSV file.
+var TidyCSVHeader = []string{"nmi", "suffix", "interval_start", "interval_end", "value", "uom", "quality"}
+
+// WriteTidyCSV writes the readings to w as long-format CSV rows, one per reading,
+// in the columns of TidyCSVHeader. Interval times are RFC 3339 instants with the
+// NEM time offset.
+// The header itself is only written when header is set, so a file can be
+// written to in several calls.
+func WriteTidyCSV(w io.Writer, readings []csv.Reading, header bool) error {
+	writer := stdcsv.NewWriter(w)
+	if header {
+		if err := writer.Write(TidyCSVHeader); err != nil {
+			return fmt.Errorf("failed to write header: %v", err)
+		}
+	}
+
+	for _, reading := range readings {
+		end := csv.InNEMTime(reading.Timestamp)
+		start := end.Add(-time.Duration(reading.IntervalLength) * time.Minute)
+		record := []string{
+			reading.Nmi,
+			reading.NmiSuffix,
+			start.Format(time.RFC3339),
+			end.Format(time.RFC3339),
+			reading.Consumption.String(),
+			reading.Uom,
+			reading.QualityMethod,
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write reading: %v", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"bytes"
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteTidyCSV(t *testing.T) {
+	readings := []csv.Reading{
+		{
+			MeterReadings: model.MeterReadings{
+				Nmi:         "NMI1",
+				NmiSuffix:   "E1",
+				Timestamp:   time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC),
+				Consumption: decimal.RequireFromString("0.461"),
+			},
+			Uom:            "kWh",
+			IntervalLength: 30,
+			QualityMethod:  "A",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		header   bool
+		expected string
+	}{
+		{
+			name:     "With header",
+			header:   true,
+			expected: "nmi,suffix,interval_start,interval_end,value,uom,quality\nNMI1,E1,2005-03-01T00:00:00+10:00,2005-03-01T00:30:00+10:00,0.461,kWh,A\n",
+		},
+		{
+			name:     "Without header",
+			expected: "NMI1,E1,2005-03-01T00:00:00+10:00,2005-03-01T00:30:00+10:00,0.461,kWh,A\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteTidyCSV(&b, readings, tt.header); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("Expected\n%s\nbut got\n%s", tt.expected, b.String())
+			}
+		})
+	}
+}
@@ -1,15 +1,15 @@
 package util
 
 import (
-	"flag"
 	"fmt"
 	"path/filepath"
 )
 
-func ValidateFile(filename *string) error {
+// ValidateFile checks that a CSV file name was given, printing usage if not.
+func ValidateFile(filename *string, usage func()) error {
 	// Validate input
 	if *filename == "" {
-		flag.Usage()
+		usage()
 		return fmt.Errorf("error: CSV file name is required")
 	}
 
@@ -1,27 +1,170 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flo_energy_take_home/sql"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 )
 
-func WriteToSQLFilesParallel(statements []string, outputDir string) error {
+const (
+	manifestFileName = "manifest.json"
+	loadScriptName   = "load.sh"
+)
+
+// TransactionOverhead is how many bytes wrapping a statement in a transaction
+// adds to a statement file.
+const TransactionOverhead = len("BEGIN;\n") + len(";\nCOMMIT;\n")
+
+// Manifest records the statement files of a run, in the order they must be applied.
+type Manifest struct {
+	// Compression is the format the files are compressed in, if any, "gzip" or "zstd"
+	Compression string         `json:"compression,omitempty"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile describes one statement file.
+type ManifestFile struct {
+	// File is the path of the file relative to the output directory
+	File      string   `json:"file"`
+	Partition string   `json:"partition,omitempty"`
+	Rows      int      `json:"rows"`
+	NMIs      []string `json:"nmis"`
+	From      string   `json:"from,omitempty"`
+	To        string   `json:"to,omitempty"`
+	SHA256    string   `json:"sha256"`
+}
+
+// WriteOption configures how WriteToSQLFilesParallel writes statement files.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	compression      string
+	compressionLevel int
+	fileNames        FileNameTemplate
+	runID            string
+	source           string
+}
+
+// WithCompression compresses each statement file in format, "gzip" or "zstd",
+// at level, where 0 means the format's default level.
+func WithCompression(format string, level int) WriteOption {
+	return func(o *writeOptions) {
+		o.compression = format
+		o.compressionLevel = level
+	}
+}
+
+// WithFileNames names statement files by template, filling in {run_id} and
+// {source} from runID and source. The file extension is added to the name.
+func WithFileNames(template FileNameTemplate, runID, source string) WriteOption {
+	return func(o *writeOptions) {
+		o.fileNames = template
+		o.runID = runID
+		o.source = source
+	}
+}
+
+// WriteToSQLFilesParallel writes each batch to its own statement file, wrapped in
+// a transaction, followed by a manifest and a load.sh that applies the files in order.
+// Files are named statement_N.sql, with N zero-padded to the width of the file
+// count, unless WithFileNames is given. Batches with a partition are written to
+// a directory named after it, numbered from 1 within it, and each such
+// directory gets a load.sh of its own.
+// Files are written atomically, and existing files other than those written are
+// left alone.
+func WriteToSQLFilesParallel(batches []sql.Batch, outputDir string, opts ...WriteOption) error {
+	o := writeOptions{fileNames: FileNameTemplate{template: DefaultFileNameTemplate}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ext := ".sql"
+	if o.compression != "" {
+		if err := ValidateCompression(o.compression, o.compressionLevel); err != nil {
+			return err
+		}
+		ext += compressionExts[o.compression].ext
+	}
+
+	// Files are numbered within their partition, so that's what they're padded for
+	counts := make(map[string]int)
+	for _, batch := range batches {
+		if batch.Partition != "" && !plainName.MatchString(batch.Partition) {
+			return fmt.Errorf("partition %q can't be used as a directory name", batch.Partition)
+		}
+		counts[batch.Partition]++
+	}
+
+	names := make([]string, len(batches))
+	sequences := make(map[string]int)
+	for i, batch := range batches {
+		sequences[batch.Partition]++
+		name, err := o.fileNames.Name(FileNameFields{
+			Seq:       sequences[batch.Partition],
+			Count:     counts[batch.Partition],
+			RunID:     o.runID,
+			Source:    o.source,
+			Partition: batch.Partition,
+		})
+		if err != nil {
+			return err
+		}
+		names[i] = path.Join(batch.Partition, name+ext)
+	}
+
+	for partition := range sequences {
+		if err := os.MkdirAll(filepath.Join(outputDir, partition), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create output directory: %v", err)
+		}
+	}
 	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
 		return fmt.Errorf("failed to create output directory: %v", err)
 	}
 
-	// Clear existing statement files
-	if err := clearExistingStatementFiles(outputDir); err != nil {
-		return fmt.Errorf("failed to clear existing statement files: %v", err)
+	manifest := Manifest{Compression: o.compression, Files: make([]ManifestFile, len(batches))}
+	err := writeFilesParallel(len(batches), func(index int) error {
+		name := names[index]
+		fileName := filepath.Join(outputDir, filepath.FromSlash(name))
+		content := []byte(wrapInTransaction(batches[index].Statement))
+		if o.compression != "" {
+			compressed, err := compress(o.compression, o.compressionLevel, content)
+			if err != nil {
+				return fmt.Errorf("failed to write file %s: %v", fileName, err)
+			}
+			content = compressed
+		}
+		if err := WriteFileAtomic(fileName, content, 0644); err != nil {
+			return err
+		}
+		manifest.Files[index] = newManifestFile(name, batches[index], content)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeManifest(manifest, outputDir); err != nil {
+		return err
 	}
+	if err := writeLoadScript(manifest, outputDir); err != nil {
+		return err
+	}
+	return writePartitionLoadScripts(manifest, outputDir)
+}
 
+// writeFilesParallel calls write for each index from 0 to count-1, spread over
+// one worker per CPU, and returns the first error.
+func writeFilesParallel(count int, write func(index int) error) error {
 	numWorkers := runtime.NumCPU() // Use number of CPUs as the number of workers
-	workChan := make(chan int, len(statements))
-	errChan := make(chan error, len(statements))
+	workChan := make(chan int, count)
+	errChan := make(chan error, count)
 	var wg sync.WaitGroup
 
 	// Start worker goroutines
@@ -30,9 +173,8 @@ func WriteToSQLFilesParallel(statements []string, outputDir string) error {
 		go func() {
 			defer wg.Done()
 			for index := range workChan {
-				fileName := filepath.Join(outputDir, fmt.Sprintf("statement_%d.sql", index+1))
-				if err := os.WriteFile(fileName, []byte(statements[index]+";"), 0644); err != nil {
-					errChan <- fmt.Errorf("failed to write file %s: %v", fileName, err)
+				if err := write(index); err != nil {
+					errChan <- err
 					return
 				}
 			}
@@ -40,7 +182,7 @@ func WriteToSQLFilesParallel(statements []string, outputDir string) error {
 	}
 
 	// Send work to goroutines
-	for i := range statements {
+	for i := 0; i < count; i++ {
 		workChan <- i
 	}
 	close(workChan)
@@ -55,27 +197,91 @@ func WriteToSQLFilesParallel(statements []string, outputDir string) error {
 			return err // Return the first error encountered
 		}
 	}
-
 	return nil
 }
 
-func clearExistingStatementFiles(dir string) error {
-	entries, err := os.ReadDir(dir)
+// wrapInTransaction makes a statement file all-or-nothing.
+func wrapInTransaction(statement string) string {
+	statement = strings.TrimRight(strings.TrimSpace(statement), ";")
+	return "BEGIN;\n" + statement + ";\nCOMMIT;\n"
+}
+
+func newManifestFile(name string, batch sql.Batch, content []byte) ManifestFile {
+	sum := sha256.Sum256(content)
+	file := ManifestFile{
+		File:      name,
+		Partition: batch.Partition,
+		Rows:      batch.Rows,
+		NMIs:      batch.NMIs,
+		SHA256:    hex.EncodeToString(sum[:]),
+	}
+	if file.NMIs == nil {
+		file.NMIs = []string{}
+	}
+	if batch.Rows > 0 {
+		file.From = batch.From.Format("2006-01-02 15:04:05")
+		file.To = batch.To.Format("2006-01-02 15:04:05")
+	}
+	return file
+}
+
+func writeManifest(manifest Manifest, outputDir string) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to encode manifest: %v", err)
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
+	fileName := filepath.Join(outputDir, manifestFileName)
+	return WriteFileAtomic(fileName, append(content, '\n'), 0644)
+}
+
+// writeLoadScript writes a script that runs each statement file through psql in
+// manifest order, stopping at the first file that fails. Any arguments to the
+// script, such as a connection string, are passed on to psql. Compressed files
+// are checked before being streamed into psql, as a pipeline's exit status only
+// reflects psql.
+func writeLoadScript(manifest Manifest, outputDir string) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Applies the statement files in order, stopping at the first failure.\n")
+	b.WriteString("# Usage: ./load.sh [psql connection options]\n")
+	b.WriteString("set -e\n")
+	b.WriteString("cd \"$(dirname \"$0\")\"\n")
+	for _, file := range manifest.Files {
+		if manifest.Compression == "" {
+			fmt.Fprintf(&b, "psql -v ON_ERROR_STOP=1 -f %s \"$@\"\n", file.File)
 			continue
 		}
-		if strings.HasPrefix(entry.Name(), "statement_") && strings.HasSuffix(entry.Name(), ".sql") {
-			fullPath := filepath.Join(dir, entry.Name())
-			if err := os.Remove(fullPath); err != nil {
-				return fmt.Errorf("failed to remove file %s: %v", fullPath, err)
-			}
+		decompress := compressionExts[manifest.Compression].decompress
+		fmt.Fprintf(&b, "%s -tq %s\n", decompress, file.File)
+		fmt.Fprintf(&b, "%s -dc %s | psql -v ON_ERROR_STOP=1 -f - \"$@\"\n", decompress, file.File)
+	}
+
+	fileName := filepath.Join(outputDir, loadScriptName)
+	return WriteFileAtomic(fileName, []byte(b.String()), 0755)
+}
+
+// writePartitionLoadScripts writes a load.sh into each partition directory that
+// applies just that partition's files, so one partition can be reloaded alone.
+func writePartitionLoadScripts(manifest Manifest, outputDir string) error {
+	var partitions []string
+	files := make(map[string][]ManifestFile)
+	for _, file := range manifest.Files {
+		if file.Partition == "" {
+			continue
 		}
+		if _, ok := files[file.Partition]; !ok {
+			partitions = append(partitions, file.Partition)
+		}
+		file.File = path.Base(file.File)
+		files[file.Partition] = append(files[file.Partition], file)
 	}
 
+	for _, partition := range partitions {
+		partitionManifest := Manifest{Compression: manifest.Compression, Files: files[partition]}
+		if err := writeLoadScript(partitionManifest, filepath.Join(outputDir, partition)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
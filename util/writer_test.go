@@ -1,12 +1,26 @@
 package util
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"flo_energy_take_home/sql"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
+func batchesFromStatements(statements []string) []sql.Batch {
+	batches := make([]sql.Batch, len(statements))
+	for i, statement := range statements {
+		batches[i] = sql.Batch{Statement: statement}
+	}
+	return batches
+}
+
 func TestWriteToSQLFilesParallel(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -25,16 +39,16 @@ func TestWriteToSQLFilesParallel(t *testing.T) {
 				"INSERT INTO table1 VALUES (3, 'test3')",
 			},
 			expectError:   false,
-			expectedFiles: 3,
+			expectedFiles: 5,
 		},
 		{
 			name:          "Empty statements slice",
 			statements:    []string{},
 			expectError:   false,
-			expectedFiles: 0,
+			expectedFiles: 2,
 		},
 		{
-			name: "Clear existing files",
+			name: "Existing files are left alone",
 			statements: []string{
 				"INSERT INTO table1 VALUES (1, 'new1')",
 			},
@@ -43,7 +57,7 @@ func TestWriteToSQLFilesParallel(t *testing.T) {
 				"statement_3.sql",
 			},
 			expectError:   false,
-			expectedFiles: 1,
+			expectedFiles: 5,
 		},
 		{
 			name: "Error - permission denied",
@@ -86,7 +100,7 @@ func TestWriteToSQLFilesParallel(t *testing.T) {
 			}
 
 			// Run the function
-			err = WriteToSQLFilesParallel(tt.statements, tempDir)
+			err = WriteToSQLFilesParallel(batchesFromStatements(tt.statements), tempDir)
 
 			// Check error expectation
 			if tt.expectError && err == nil {
@@ -113,17 +127,17 @@ func TestWriteToSQLFilesParallel(t *testing.T) {
 						t.Errorf("Failed to read file %s: %v", fileName, err)
 						continue
 					}
-					expectedContent := statement + ";"
+					expectedContent := "BEGIN;\n" + statement + ";\nCOMMIT;\n"
 					if string(content) != expectedContent {
 						t.Errorf("File %s content mismatch. Expected: %s, Got: %s", fileName, expectedContent, string(content))
 					}
 				}
 			}
 
-			// Check that old files were cleared
+			// Check that old files were left alone
 			for _, filename := range tt.existingFiles {
-				if _, err := os.Stat(filepath.Join(tempDir, filename)); !os.IsNotExist(err) {
-					t.Errorf("Expected file %s to be deleted, but it still exists", filename)
+				if _, err := os.Stat(filepath.Join(tempDir, filename)); err != nil {
+					t.Errorf("Expected file %s to be left alone: %v", filename, err)
 				}
 			}
 
@@ -150,22 +164,237 @@ func TestWriteToSQLFilesParallel_ConcurrencyAndRaceConditions(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	err = WriteToSQLFilesParallel(statements, tempDir)
+	err = WriteToSQLFilesParallel(batchesFromStatements(statements), tempDir)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
 	// Check that all files were created and contain correct content
 	for i, statement := range statements {
-		fileName := filepath.Join(tempDir, fmt.Sprintf("statement_%d.sql", i+1))
+		// 1000 files are numbered from 0001, so they sort in order
+		fileName := filepath.Join(tempDir, fmt.Sprintf("statement_%04d.sql", i+1))
 		content, err := os.ReadFile(fileName)
 		if err != nil {
 			t.Errorf("Failed to read file %s: %v", fileName, err)
 			continue
 		}
-		expectedContent := statement + ";"
+		expectedContent := "BEGIN;\n" + statement + ";\nCOMMIT;\n"
 		if string(content) != expectedContent {
 			t.Errorf("File %s content mismatch. Expected: %s, Got: %s", fileName, expectedContent, string(content))
 		}
 	}
 }
+
+func TestWriteToSQLFilesParallel_ManifestAndLoadScript(t *testing.T) {
+	batches := []sql.Batch{
+		{
+			Statement: "INSERT INTO table1 VALUES (1, 'test1');\n",
+			Rows:      2,
+			NMIs:      []string{"NMI1", "NMI2"},
+			From:      time.Date(2005, 3, 1, 0, 30, 0, 0, time.UTC),
+			To:        time.Date(2005, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{Statement: "INSERT INTO table1 VALUES (2, 'test2')", Rows: 1, NMIs: []string{"NMI3"}},
+	}
+
+	tempDir, err := os.MkdirTemp("", "sqltest_manifest")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := WriteToSQLFilesParallel(batches, tempDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "statement_1.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read statement file: %v", err)
+	}
+	if string(content) != "BEGIN;\nINSERT INTO table1 VALUES (1, 'test1');\nCOMMIT;\n" {
+		t.Errorf("Statement already ending in a semicolon was not wrapped cleanly: %q", content)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("Expected 2 manifest entries, but got %d", len(manifest.Files))
+	}
+	first := manifest.Files[0]
+	if first.File != "statement_1.sql" || first.Rows != 2 || len(first.NMIs) != 2 {
+		t.Errorf("Unexpected manifest entry: %+v", first)
+	}
+	if first.From != "2005-03-01 00:30:00" || first.To != "2005-03-02 00:00:00" {
+		t.Errorf("Unexpected date range: %s to %s", first.From, first.To)
+	}
+	if len(first.SHA256) != 64 {
+		t.Errorf("Expected a SHA-256 hex digest, but got %q", first.SHA256)
+	}
+
+	script, err := os.ReadFile(filepath.Join(tempDir, "load.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read load script: %v", err)
+	}
+	firstIndex := strings.Index(string(script), "-f statement_1.sql")
+	secondIndex := strings.Index(string(script), "-f statement_2.sql")
+	if firstIndex < 0 || secondIndex < firstIndex {
+		t.Errorf("Load script doesn't apply files in order:\n%s", script)
+	}
+	if !strings.Contains(string(script), "ON_ERROR_STOP=1") || !strings.Contains(string(script), "set -e") {
+		t.Errorf("Load script doesn't stop on the first failure:\n%s", script)
+	}
+}
+
+func TestWriteToSQLFilesParallel_Compression(t *testing.T) {
+	batches := batchesFromStatements([]string{
+		"INSERT INTO table1 VALUES (1, 'test1')",
+		"INSERT INTO table1 VALUES (2, 'test2')",
+	})
+
+	tempDir, err := os.MkdirTemp("", "sqltest_compression")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := WriteToSQLFilesParallel(batches, tempDir, WithCompression("gzip", 9)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(tempDir, "statement_1.sql.gz"))
+	if err != nil {
+		t.Fatalf("Failed to open statement file: %v", err)
+	}
+	defer file.Close()
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to decompress statement file: %v", err)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to decompress statement file: %v", err)
+	}
+	if string(content) != "BEGIN;\nINSERT INTO table1 VALUES (1, 'test1');\nCOMMIT;\n" {
+		t.Errorf("Unexpected statement file content: %q", content)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if manifest.Compression != "gzip" || manifest.Files[1].File != "statement_2.sql.gz" {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	script, err := os.ReadFile(filepath.Join(tempDir, "load.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read load script: %v", err)
+	}
+	if !strings.Contains(string(script), "gzip -tq statement_1.sql.gz\ngzip -dc statement_1.sql.gz | psql -v ON_ERROR_STOP=1 -f - \"$@\"\n") {
+		t.Errorf("Load script doesn't stream the compressed files into psql:\n%s", script)
+	}
+
+	if err := WriteToSQLFilesParallel(batches, tempDir, WithCompression("bzip2", 0)); err == nil {
+		t.Errorf("Expected an error for an unknown compression format, but got none")
+	}
+}
+
+func TestWriteToSQLFilesParallel_Partitions(t *testing.T) {
+	batches := []sql.Batch{
+		{Statement: "INSERT INTO table1 VALUES (1, 'NMI1')", Rows: 1, Partition: "NMI1"},
+		{Statement: "INSERT INTO table1 VALUES (2, 'NMI1')", Rows: 1, Partition: "NMI1"},
+		{Statement: "INSERT INTO table1 VALUES (3, 'NMI2')", Rows: 1, Partition: "NMI2"},
+	}
+
+	tempDir, err := os.MkdirTemp("", "sqltest_partitions")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := WriteToSQLFilesParallel(batches, tempDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"NMI1/statement_1.sql", "NMI1/statement_2.sql", "NMI2/statement_1.sql", "NMI1/load.sh", "NMI2/load.sh"} {
+		if _, err := os.Stat(filepath.Join(tempDir, name)); err != nil {
+			t.Errorf("Expected file %s: %v", name, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if manifest.Files[2].File != "NMI2/statement_1.sql" || manifest.Files[2].Partition != "NMI2" {
+		t.Errorf("Unexpected manifest entry: %+v", manifest.Files[2])
+	}
+
+	script, err := os.ReadFile(filepath.Join(tempDir, "load.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read load script: %v", err)
+	}
+	if !strings.Contains(string(script), "-f NMI2/statement_1.sql") {
+		t.Errorf("Load script doesn't apply the partition files:\n%s", script)
+	}
+	partitionScript, err := os.ReadFile(filepath.Join(tempDir, "NMI1", "load.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read partition load script: %v", err)
+	}
+	if !strings.Contains(string(partitionScript), "-f statement_2.sql") || strings.Contains(string(partitionScript), "NMI2") {
+		t.Errorf("Partition load script doesn't apply just its own files:\n%s", partitionScript)
+	}
+
+	batches[0].Partition = "../NMI1"
+	if err := WriteToSQLFilesParallel(batches, tempDir); err == nil {
+		t.Errorf("Expected an error for a partition outside the output directory, but got none")
+	}
+}
+
+func TestWriteToSQLFilesParallel_FileNames(t *testing.T) {
+	batches := batchesFromStatements(make([]string, 10))
+	batches[9].Partition = "NMI1"
+
+	tempDir, err := os.MkdirTemp("", "sqltest_names")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	template, err := ParseFileNameTemplate("{source}_{run_id}_{seq:3}")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := WriteToSQLFilesParallel(batches, tempDir, WithFileNames(template, "run1", "example")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"example_run1_001.sql", "example_run1_009.sql", "NMI1/example_run1_001.sql"} {
+		if _, err := os.Stat(filepath.Join(tempDir, name)); err != nil {
+			t.Errorf("Expected file %s: %v", name, err)
+		}
+	}
+
+	// Padded names sort in load order
+	matches, err := filepath.Glob(filepath.Join(tempDir, "*.sql"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(matches) != 9 || filepath.Base(matches[8]) != "example_run1_009.sql" {
+		t.Errorf("Unexpected statement files: %v", matches)
+	}
+}
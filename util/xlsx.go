@@ -0,0 +1,172 @@
+package util
+
+import (
+	"flo_energy_take_home/csv"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+const summarySheet = "Summary"
+
+// xlsxDay is one row of an NMI sheet: a channel's readings for one day.
+type xlsxDay struct {
+	suffix string
+	uom    string
+	date   time.Time
+	total  decimal.Decimal
+	count  int
+	// values by minutes from the start of the day to the end of the interval
+	values map[int]decimal.Decimal
+}
+
+// WriteXLSX writes the readings to an Excel workbook at fileName. A summary sheet
+// lists the daily total of every NMI and suffix, followed by a sheet per NMI in
+// wide layout, with a row per suffix and day and a column per interval.
+func WriteXLSX(readings []csv.Reading, fileName string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	days, nmis := xlsxDays(readings)
+
+	if err := f.SetSheetName("Sheet1", summarySheet); err != nil {
+		return fmt.Errorf("failed to create sheet %s: %v", summarySheet, err)
+	}
+	if err := writeSummarySheet(f, days, nmis); err != nil {
+		return err
+	}
+	for _, nmi := range nmis {
+		if _, err := f.NewSheet(nmi); err != nil {
+			return fmt.Errorf("failed to create sheet %s: %v", nmi, err)
+		}
+		if err := writeNMISheet(f, nmi, days[nmi]); err != nil {
+			return err
+		}
+	}
+
+	file, err := CreateAtomic(fileName, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := f.WriteTo(file); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", fileName, err)
+	}
+	return file.Commit()
+}
+
+// xlsxDays groups readings by NMI, then suffix and day, returning the days of
+// each NMI sorted by suffix and date, and the NMIs sorted.
+func xlsxDays(readings []csv.Reading) (map[string][]*xlsxDay, []string) {
+	index := make(map[string]*xlsxDay)
+	days := make(map[string][]*xlsxDay)
+
+	for _, reading := range readings {
+		date := csv.ReadingDay(reading.Timestamp)
+		key := reading.Nmi + "|" + reading.NmiSuffix + "|" + date.Format("2006-01-02")
+
+		day, ok := index[key]
+		if !ok {
+			day = &xlsxDay{suffix: reading.NmiSuffix, uom: reading.Uom, date: date, total: decimal.Zero, values: make(map[int]decimal.Decimal)}
+			index[key] = day
+			days[reading.Nmi] = append(days[reading.Nmi], day)
+		}
+		day.values[int(reading.Timestamp.Sub(date)/time.Minute)] = reading.Consumption
+		day.total = day.total.Add(reading.Consumption)
+		day.count++
+	}
+
+	nmis := make([]string, 0, len(days))
+	for nmi, nmiDays := range days {
+		nmis = append(nmis, nmi)
+		sort.Slice(nmiDays, func(i, j int) bool {
+			if nmiDays[i].suffix != nmiDays[j].suffix {
+				return nmiDays[i].suffix < nmiDays[j].suffix
+			}
+			return nmiDays[i].date.Before(nmiDays[j].date)
+		})
+	}
+	sort.Strings(nmis)
+
+	return days, nmis
+}
+
+func writeSummarySheet(f *excelize.File, days map[string][]*xlsxDay, nmis []string) error {
+	sw, err := f.NewStreamWriter(summarySheet)
+	if err != nil {
+		return fmt.Errorf("failed to write sheet %s: %v", summarySheet, err)
+	}
+
+	rows := [][]interface{}{{"NMI", "Suffix", "Date", "UOM", "Total", "Intervals"}}
+	for _, nmi := range nmis {
+		for _, day := range days[nmi] {
+			total, _ := day.total.Float64()
+			rows = append(rows, []interface{}{nmi, day.suffix, day.date.Format("2006-01-02"), day.uom, total, day.count})
+		}
+	}
+
+	return writeSheetRows(sw, summarySheet, rows)
+}
+
+// writeNMISheet writes an NMI's days with a column per interval, at the shortest
+// interval length of any of its readings. Each value is in the column of the
+// time its interval ends.
+func writeNMISheet(f *excelize.File, nmi string, days []*xlsxDay) error {
+	sw, err := f.NewStreamWriter(nmi)
+	if err != nil {
+		return fmt.Errorf("failed to write sheet %s: %v", nmi, err)
+	}
+
+	step := 1440
+	for _, day := range days {
+		for minutes := range day.values {
+			step = gcd(step, minutes)
+		}
+	}
+
+	header := []interface{}{"Date", "Suffix", "UOM"}
+	for minutes := step; minutes <= 1440; minutes += step {
+		header = append(header, fmt.Sprintf("%02d:%02d", minutes/60, minutes%60))
+	}
+	rows := [][]interface{}{header}
+	for _, day := range days {
+		row := []interface{}{day.date.Format("2006-01-02"), day.suffix, day.uom}
+		for minutes := step; minutes <= 1440; minutes += step {
+			if value, ok := day.values[minutes]; ok {
+				v, _ := value.Float64()
+				row = append(row, v)
+			} else {
+				row = append(row, nil)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return writeSheetRows(sw, nmi, rows)
+}
+
+func writeSheetRows(sw *excelize.StreamWriter, sheet string, rows [][]interface{}) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return fmt.Errorf("failed to write sheet %s: %v", sheet, err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("failed to write sheet %s: %v", sheet, err)
+	}
+	return nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
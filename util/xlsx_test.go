@@ -0,0 +1,75 @@
+package util
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/db/test_flo/public/model"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	march1 := time.Date(2005, 3, 1, 0, 0, 0, 0, time.UTC)
+	var readings []csv.Reading
+	for _, nmi := range []string{"NMI2", "NMI1"} {
+		for i := 1; i <= 48; i++ {
+			readings = append(readings, csv.Reading{
+				MeterReadings: model.MeterReadings{
+					Nmi:         nmi,
+					NmiSuffix:   "E1",
+					Timestamp:   march1.Add(time.Duration(i) * 30 * time.Minute),
+					Consumption: decimal.RequireFromString("0.25"),
+				},
+				Uom: "kWh",
+			})
+		}
+	}
+	// A second channel on 15 minute intervals, with one reading
+	readings = append(readings, csv.Reading{MeterReadings: model.MeterReadings{Nmi: "NMI1", NmiSuffix: "B1", Timestamp: march1.Add(15 * time.Minute), Consumption: decimal.RequireFromString("1.5")}, Uom: "kWh"})
+
+	fileName := filepath.Join(t.TempDir(), "readings.xlsx")
+	if err := WriteXLSX(readings, fileName); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); !reflect.DeepEqual(sheets, []string{"Summary", "NMI1", "NMI2"}) {
+		t.Errorf("Expected sheets Summary, NMI1 and NMI2, but got %v", sheets)
+	}
+
+	tests := []struct {
+		sheet    string
+		cell     string
+		expected string
+	}{
+		{sheet: "Summary", cell: "A2", expected: "NMI1"},
+		{sheet: "Summary", cell: "B2", expected: "B1"},
+		{sheet: "Summary", cell: "E3", expected: "12"},
+		{sheet: "Summary", cell: "F3", expected: "48"},
+		{sheet: "Summary", cell: "A4", expected: "NMI2"},
+		// NMI1 has a 15 minute channel, so its columns are every 15 minutes
+		{sheet: "NMI1", cell: "D1", expected: "00:15"},
+		{sheet: "NMI1", cell: "D2", expected: "1.5"},
+		{sheet: "NMI1", cell: "E3", expected: "0.25"},
+		{sheet: "NMI1", cell: "D3", expected: ""},
+		{sheet: "NMI2", cell: "D1", expected: "00:30"},
+		{sheet: "NMI2", cell: "AY1", expected: "24:00"},
+	}
+	for _, tt := range tests {
+		value, err := f.GetCellValue(tt.sheet, tt.cell)
+		if err != nil {
+			t.Errorf("Unexpected error reading %s!%s: %v", tt.sheet, tt.cell, err)
+		} else if value != tt.expected {
+			t.Errorf("Expected %s!%s to be %q, but got %q", tt.sheet, tt.cell, tt.expected, value)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flo_energy_take_home/csv"
+	"flo_energy_take_home/util"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// runValidate checks a NEM12 file with every structural and value check and
+// prints a conformance report, without writing any output. It fails if any
+// problem is found.
+func runValidate(args []string) error {
+	fs := newFlagSet("validate", "Checks a NEM12 file and prints a conformance report, without writing any output.\nExits non-zero if any problem is found.")
+	filename := fs.String("file", "", "CSV file to check")
+	fs.Parse(args)
+
+	if err := util.ValidateFile(filename, fs.Usage); err != nil {
+		return err
+	}
+	file, err := os.Open(*filename)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	report, err := csv.Validate(file)
+	if err != nil {
+		return err
+	}
+	printReport(*filename, report)
+
+	if !report.Valid() {
+		return fmt.Errorf("%s failed validation with %d problems", *filename, len(report.Problems))
+	}
+	return nil
+}
+
+func printReport(filename string, report csv.Report) {
+	fmt.Printf("File: %s\n\nRecords:\n", filename)
+	recordTypes := make([]string, 0, len(report.Records))
+	for recordType := range report.Records {
+		recordTypes = append(recordTypes, recordType)
+	}
+	sort.Strings(recordTypes)
+	for _, recordType := range recordTypes {
+		fmt.Printf("  %s: %d\n", recordType, report.Records[recordType])
+	}
+
+	fmt.Printf("\nNMIs: %d", len(report.NMIs))
+	if len(report.NMIs) > 0 {
+		fmt.Printf(" (%s)", strings.Join(report.NMIs, ", "))
+	}
+	fmt.Printf("\nChannels: %d\nReadings: %d\n", report.Channels, report.Readings)
+	if !report.From.IsZero() {
+		fmt.Printf("Dates: %s to %s\n", report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+	}
+
+	fmt.Printf("\nProblems: %d\n", len(report.Problems))
+	for _, problem := range report.Problems {
+		fmt.Printf("  %s\n", problem)
+	}
+
+	if report.Valid() {
+		fmt.Println("\nOK")
+	} else {
+		fmt.Println("\nFAILED")
+	}
+}